@@ -0,0 +1,78 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/focela/cronx/pkg/runner"
+)
+
+// admissionRequest is the body posted to the admission webhook.
+type admissionRequest struct {
+	Job       string    `json:"job"`
+	RunID     string    `json:"run_id"`
+	Scheduled time.Time `json:"scheduled"`
+	Command   string    `json:"command"`
+	Args      []string  `json:"args"`
+}
+
+// admissionWebhook asks an external controller whether each run may start.
+type admissionWebhook struct {
+	url     string
+	command string
+	args    []string
+	// failOpen admits runs when the webhook cannot be reached.
+	failOpen bool
+	timeout  time.Duration
+	client   *http.Client
+}
+
+// admit posts run to the webhook and returns an error if the run is
+// denied. Any status other than 200 denies the run; transport errors
+// follow the fail-open policy.
+func (a *admissionWebhook) admit(run *runner.Run) error {
+	body, err := json.Marshal(admissionRequest{
+		Job:       run.Job,
+		RunID:     run.ID,
+		Scheduled: run.Scheduled,
+		Command:   a.command,
+		Args:      a.args,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode admission request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create admission request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		err = redactURLError(err)
+		if a.failOpen {
+			logger.Warn("admission webhook failed, admitting run", "run_id", run.ID, "error", err)
+			return nil
+		}
+		return fmt.Errorf("admission webhook failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("admission webhook denied run with status %d", resp.StatusCode)
+	}
+	return nil
+}
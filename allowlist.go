@@ -0,0 +1,74 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// allowlist holds the commands permitted by --command-allowlist.
+type allowlist struct {
+	// names holds bare command names, matched against base names.
+	names map[string]bool
+	// paths holds absolute paths, matched against resolved commands.
+	paths map[string]bool
+}
+
+// loadAllowlist reads one command name or path per line from path,
+// skipping blank lines and comments. Relative paths are resolved against
+// the current directory.
+func loadAllowlist(path string) (*allowlist, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open command allowlist: %w", err)
+	}
+	defer f.Close()
+
+	a := &allowlist{names: make(map[string]bool), paths: make(map[string]bool)}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !strings.ContainsRune(line, filepath.Separator) && !strings.Contains(line, "/") {
+			a.names[line] = true
+			continue
+		}
+		abs, err := filepath.Abs(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path '%s' in command allowlist: %w", line, err)
+		}
+		a.paths[abs] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read command allowlist: %w", err)
+	}
+	return a, nil
+}
+
+// check returns an error unless command, as resolved through PATH, is
+// permitted by name or by path.
+func (a *allowlist) check(command string) error {
+	resolved, err := exec.LookPath(command)
+	if err != nil {
+		return fmt.Errorf("command '%s' not found: %w", command, err)
+	}
+	if abs, err := filepath.Abs(resolved); err == nil && a.paths[abs] {
+		return nil
+	}
+	if a.names[filepath.Base(resolved)] {
+		return nil
+	}
+	return fmt.Errorf("command '%s' (%s) is not on the allowlist", command, resolved)
+}
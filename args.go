@@ -0,0 +1,37 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+// loadArgsFile reads one argument per line from path, skipping empty
+// lines. Lines are used verbatim, without quoting or comments.
+func loadArgsFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open args file: %w", err)
+	}
+	defer f.Close()
+
+	var args []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			args = append(args, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read args file: %w", err)
+	}
+	return args, nil
+}
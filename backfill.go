@@ -0,0 +1,173 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/focela/cronx/pkg/runner"
+)
+
+// backfillLayouts are the accepted formats of --from and --to.
+var backfillLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// backfill runs the command once for every fire time of a schedule in a
+// past time range, injecting each fire time as CRONX_SCHEDULED_TIME.
+func backfill(args []string) error {
+	fs := flag.NewFlagSet("backfill", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cronx backfill --schedule spec --from time --to time [flags] [--] command [args ...]")
+		fs.PrintDefaults()
+	}
+	spec := fs.String("schedule", "", "schedule whose fire times are backfilled")
+	from := fs.String("from", "", "start of the range, inclusive (YYYY-MM-DD or RFC 3339)")
+	to := fs.String("to", "", "end of the range, inclusive (YYYY-MM-DD or RFC 3339)")
+	tz := fs.String("tz", "", "time zone the schedule and range are evaluated in (default local)")
+	utc := fs.Bool("utc", false, "evaluate the schedule and range in UTC; shortcut for --tz UTC")
+	syntax := fs.String("cron-syntax", "optional-seconds", "schedule syntax: optional-seconds, standard, with-seconds or quartz (with-seconds that rejects Quartz-only fields)")
+	parallel := fs.Int("parallel", 1, "number of occurrences run at the same time")
+	stopOnFailure := fs.Bool("stop-on-failure", false, "start no further occurrences once one has failed")
+	expandEnv := fs.Bool("expand-env", false, "expand $VAR and ${VAR} in the command's arguments, e.g. $CRONX_SCHEDULED_TIME")
+	fs.Parse(args)
+
+	if *spec == "" || *from == "" || *to == "" || fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *parallel < 1 {
+		return errors.New("--parallel must be at least 1")
+	}
+	if strings.HasPrefix(*spec, "@after ") {
+		return errors.New("@after schedules have no fixed fire times to backfill")
+	}
+
+	loc, err := loadLocation(*tz, *utc)
+	if err != nil {
+		return err
+	}
+	start, err := parseBackfillTime(*from, loc)
+	if err != nil {
+		return fmt.Errorf("invalid --from: %w", err)
+	}
+	end, err := parseBackfillTime(*to, loc)
+	if err != nil {
+		return fmt.Errorf("invalid --to: %w", err)
+	}
+	if end.Before(start) {
+		return errors.New("--to must not be before --from")
+	}
+
+	sched, err := runner.Parse(*spec, *syntax)
+	if err != nil {
+		return err
+	}
+	var times []time.Time
+	// Next returns times strictly after its argument, so step back to
+	// include a fire time at the very start of the range.
+	for t := sched.Next(start.Add(-time.Nanosecond)); !t.IsZero() && !t.After(end); t = sched.Next(t) {
+		times = append(times, t)
+	}
+	if len(times) == 0 {
+		logger.Warn("no fire times in backfill range", "schedule", *spec, "from", start, "to", end)
+		return nil
+	}
+
+	r, err := runner.New(runner.Options{
+		Schedule:  *spec,
+		Command:   fs.Arg(0),
+		Args:      fs.Args()[1:],
+		Syntax:    *syntax,
+		Location:  loc,
+		ExpandEnv: *expandEnv,
+		Logger:    logger,
+	})
+	if err != nil {
+		return err
+	}
+	logger.Info("starting backfill", "schedule", *spec, "from", start, "to", end, "occurrences", len(times), "parallel", *parallel)
+
+	// Each command runs in its own process group, out of reach of a
+	// Ctrl-C at the terminal, so a signal kills the running occurrences
+	// and starts no more instead of leaving them behind.
+	var interrupted atomic.Value
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		select {
+		case sig := <-sigChan:
+			logger.InfoContext(lifecycle, "received signal",
+				"signal", signalName(sig),
+				"action", "abort",
+				"transition", "running -> aborting",
+			)
+			interrupted.Store(sig)
+			r.Kill()
+		case <-finished:
+		}
+	}()
+
+	var (
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, *parallel)
+		ran      atomic.Int64
+		failures atomic.Int64
+	)
+	for _, t := range times {
+		sem <- struct{}{}
+		if interrupted.Load() != nil || *stopOnFailure && failures.Load() > 0 {
+			<-sem
+			break
+		}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			run := r.RunAt(t)
+			ran.Add(1)
+			if run.Err != nil {
+				failures.Add(1)
+				logger.Error("backfill occurrence failed", "scheduled", t, "run_id", run.ID, "error", run.Err)
+			}
+		})
+	}
+	wg.Wait()
+
+	attrs := []any{"runs", ran.Load(), "failures", failures.Load()}
+	if skipped := int64(len(times)) - ran.Load(); skipped > 0 {
+		attrs = append(attrs, "not_run", skipped)
+	}
+	logger.Info("backfill finished", attrs...)
+	if sig, ok := interrupted.Load().(os.Signal); ok {
+		return fmt.Errorf("interrupted by %s", signalName(sig))
+	}
+	if n := failures.Load(); n > 0 {
+		return fmt.Errorf("%d of %d occurrences failed", n, ran.Load())
+	}
+	return nil
+}
+
+// parseBackfillTime parses s in one of backfillLayouts; times without a
+// zone are taken in loc.
+func parseBackfillTime(s string, loc *time.Location) (time.Time, error) {
+	for _, layout := range backfillLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t.In(loc), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("'%s' is not a date or RFC 3339 time", s)
+}
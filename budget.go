@@ -0,0 +1,150 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/focela/cronx/pkg/runner"
+)
+
+// budgetState is the --state-file content.
+type budgetState struct {
+	Day  string `json:"budget_day"`
+	Used string `json:"budget_used"`
+}
+
+// runtimeBudget caps the total execution time of runs per calendar day.
+// A run is admitted while any budget is left, so the last run of a day
+// may overshoot it.
+type runtimeBudget struct {
+	limit time.Duration
+	loc   *time.Location
+	// path persists the usage across restarts; empty keeps it in memory.
+	path string
+
+	mu        sync.Mutex
+	day       string
+	used      time.Duration
+	exhausted bool
+}
+
+// newRuntimeBudget returns a budget of limit per day in loc, restoring
+// today's usage from path if it exists.
+func newRuntimeBudget(limit time.Duration, loc *time.Location, path string) (*runtimeBudget, error) {
+	b := &runtimeBudget{limit: limit, loc: loc, path: path, day: time.Now().In(loc).Format(dateLayout)}
+	if path == "" {
+		return b, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var st budgetState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("invalid state file: %w", err)
+	}
+	if st.Day == b.day {
+		if b.used, err = time.ParseDuration(st.Used); err != nil {
+			return nil, fmt.Errorf("invalid state file: %w", err)
+		}
+		logger.Info("restored daily runtime budget", "day", b.day, "used", b.used.String(), "budget", limit.String())
+	}
+	return b, nil
+}
+
+// rollover starts a new day's budget once the date has changed. The
+// caller must hold mu.
+func (b *runtimeBudget) rollover() {
+	today := time.Now().In(b.loc).Format(dateLayout)
+	if today == b.day {
+		return
+	}
+	if b.exhausted {
+		logger.Info("daily runtime budget reset", "day", today, "budget", b.limit.String())
+	}
+	b.day, b.used, b.exhausted = today, 0, false
+}
+
+// admit denies runs once today's budget is used up.
+func (b *runtimeBudget) admit(run *runner.Run) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.rollover()
+	if b.used < b.limit {
+		return nil
+	}
+	if !b.exhausted {
+		b.exhausted = true
+		logger.Warn("daily runtime budget exhausted", "day", b.day, "used", b.used.String(), "budget", b.limit.String())
+	}
+	return fmt.Errorf("daily runtime budget of %s exhausted", b.limit)
+}
+
+// record adds a finished run's duration to today's usage and saves it.
+func (b *runtimeBudget) record(run *runner.Run) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.rollover()
+	b.used += run.Duration
+	if err := b.save(); err != nil {
+		logger.Error("failed to write state file", "path", b.path, "error", err)
+	}
+}
+
+// checkStateFile returns an error unless save can write path. The file is
+// replaced through a temporary file next to it, so its directory must
+// accept one.
+func checkStateFile(path string) error {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".cronx-state-*")
+	if err != nil {
+		return fmt.Errorf("not writable: %w", err)
+	}
+	tmp.Close()
+	return os.Remove(tmp.Name())
+}
+
+// save writes the usage to path through a temporary file, so a crash
+// never leaves it half written. The caller must hold mu.
+func (b *runtimeBudget) save() error {
+	if b.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(budgetState{Day: b.day, Used: b.used.String()})
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(b.path), ".cronx-state-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), b.path)
+}
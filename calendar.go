@@ -0,0 +1,46 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// dateLayout is the format of dates in a --holidays file.
+const dateLayout = "2006-01-02"
+
+// loadHolidays reads one YYYY-MM-DD date per line from path, skipping
+// blank lines and comments.
+func loadHolidays(path string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open holidays file: %w", err)
+	}
+	defer f.Close()
+
+	holidays := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := time.Parse(dateLayout, line); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid date '%s', expected YYYY-MM-DD", path, n, line)
+		}
+		holidays[line] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read holidays file: %w", err)
+	}
+	return holidays, nil
+}
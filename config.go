@@ -0,0 +1,262 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+)
+
+// redacted replaces secret values in dumped configuration.
+const redacted = "REDACTED"
+
+// redactURL returns raw with everything after the host redacted, since
+// webhook URLs commonly carry their token in the path or query.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return redacted
+	}
+	return u.Scheme + "://" + u.Host + "/" + redacted
+}
+
+// redactURLError redacts the URL that net/http includes in request
+// errors.
+func redactURLError(err error) error {
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		return &url.Error{Op: ue.Op, URL: redactURL(ue.URL), Err: ue.Err}
+	}
+	return err
+}
+
+// effectiveConfig is the configuration printed by cronx dump-config:
+// what buildOptions resolved the flags, files and environment to.
+type effectiveConfig struct {
+	Job                string          `json:"job"`
+	Description        string          `json:"description,omitempty"`
+	Schedule           string          `json:"schedule"`
+	Syntax             string          `json:"cron_syntax"`
+	Location           string          `json:"location"`
+	Offset             string          `json:"schedule_offset"`
+	AlignFirstRun      bool            `json:"align_first_run"`
+	Command            string          `json:"command"`
+	Args               []string        `json:"args"`
+	Env                []string        `json:"env"`
+	EnvPassthrough     []string        `json:"env_passthrough,omitempty"`
+	ExpandEnv          bool            `json:"expand_env"`
+	ExpandStrict       bool            `json:"expand_strict"`
+	SuccessCodes       []int           `json:"success_codes"`
+	Adaptive           *adaptiveConfig `json:"adaptive_interval,omitempty"`
+	StderrIsFailure    bool            `json:"stderr_is_failure"`
+	ExpectOutput       string          `json:"expect_output_regex,omitempty"`
+	FailOutput         string          `json:"fail_output_regex,omitempty"`
+	Jitter             string          `json:"jitter"`
+	JitterSeed         uint64          `json:"jitter_seed"`
+	TimeoutPercent     int             `json:"timeout_percent"`
+	TimeoutIsSuccess   bool            `json:"timeout_as_success"`
+	TimeoutGrace       string          `json:"timeout_grace"`
+	TimeoutWarnSignal  string          `json:"timeout_warn_signal"`
+	OutputStallTimeout string          `json:"output_stall_timeout"`
+	CPUAffinity        []int           `json:"cpu_affinity,omitempty"`
+	Chroot             string          `json:"chroot,omitempty"`
+	Scratch            bool            `json:"scratch"`
+	RunDirBase         string          `json:"command_cwd_per_run,omitempty"`
+	CleanupRunDir      bool            `json:"cleanup_cwd"`
+	PTY                bool            `json:"pty"`
+	MaxLoad            float64         `json:"skip_if_load_above"`
+	WorkingDays        bool            `json:"working_schedule"`
+	Holidays           []string        `json:"holidays,omitempty"`
+	Output             string          `json:"output"`
+	LogDir             string          `json:"log_dir,omitempty"`
+	CaptureOutput      bool            `json:"capture_output"`
+
+	LogLevel  string   `json:"log_level"`
+	LogFormat string   `json:"log_format"`
+	LogSinks  []string `json:"log_sinks,omitempty"`
+	Quiet     bool     `json:"quiet"`
+
+	Notifiers        []string `json:"notifiers"`
+	SlackOn          string   `json:"slack_on,omitempty"`
+	NotifyEvents     string   `json:"notify_events,omitempty"`
+	NotifyOnRecovery bool     `json:"notify_on_recovery"`
+	FailureThreshold int      `json:"failure_threshold"`
+	PublishCommand   string   `json:"publish_command,omitempty"`
+	AdmissionWebhook string   `json:"admission_webhook,omitempty"`
+	StdinURL         string   `json:"stdin_url,omitempty"`
+	DailyBudget      string   `json:"daily_runtime_budget"`
+	StateFile        string   `json:"state_file,omitempty"`
+	ProbeCommand     string   `json:"probe_command,omitempty"`
+
+	HTTPAddr        string   `json:"http_addr,omitempty"`
+	HistoryExport   string   `json:"history_export,omitempty"`
+	ReportInterval  string   `json:"report_interval"`
+	ExitOnIdle      string   `json:"exit_on_idle"`
+	MaxLifetime     string   `json:"max_lifetime"`
+	SingletonPort   int      `json:"singleton_port,omitempty"`
+	WaitFor         []string `json:"wait_for,omitempty"`
+	SdNotify        bool     `json:"sd_notify"`
+	RestartOnChange bool     `json:"restart_on_config_change"`
+	AbortOnSigint   bool     `json:"abort_on_sigint"`
+}
+
+// adaptiveConfig is the --adaptive-interval part of effectiveConfig. A
+// zero min_interval resets to the @every interval.
+type adaptiveConfig struct {
+	NoopCode int    `json:"noop_exit_code"`
+	Min      string `json:"min_interval"`
+	Max      string `json:"max_interval"`
+}
+
+// newEffectiveConfig validates cfg and resolves it with buildOptions, as a
+// normal run would, then releases what buildOptions opened. Secret values
+// are redacted; env entries keep only their keys and --notifier webhooks
+// only their kind.
+func newEffectiveConfig(cfg *config, positionals []string) (*effectiveConfig, error) {
+	if err := validate(cfg); err != nil {
+		return nil, err
+	}
+	opts, s, err := buildOptions(cfg, positionals)
+	if err != nil {
+		return nil, err
+	}
+	if s.publish != nil {
+		s.publish.close()
+	}
+	if s.jobOutput != nil {
+		s.jobOutput.f.Close()
+	}
+	if s.idle != nil {
+		s.idle.Stop()
+	}
+
+	ec := &effectiveConfig{
+		Job:                opts.Name,
+		Description:        opts.Description,
+		Schedule:           opts.Schedule,
+		Syntax:             opts.Syntax,
+		Location:           opts.Location.String(),
+		Offset:             opts.Offset.String(),
+		AlignFirstRun:      opts.AlignFirstRun,
+		Command:            opts.Command,
+		Args:               append([]string{}, opts.Args...),
+		Env:                []string{},
+		EnvPassthrough:     opts.EnvPassthrough,
+		ExpandEnv:          opts.ExpandEnv,
+		ExpandStrict:       opts.ExpandStrict,
+		SuccessCodes:       opts.SuccessCodes,
+		StderrIsFailure:    opts.StderrIsFailure,
+		Jitter:             opts.Jitter.String(),
+		JitterSeed:         opts.JitterSeed,
+		TimeoutPercent:     opts.TimeoutPercent,
+		TimeoutIsSuccess:   opts.TimeoutIsSuccess,
+		TimeoutGrace:       opts.TimeoutGrace.String(),
+		TimeoutWarnSignal:  signalName(opts.WarnSignal),
+		OutputStallTimeout: opts.OutputStallTimeout.String(),
+		CPUAffinity:        opts.CPUAffinity,
+		Chroot:             opts.Chroot,
+		Scratch:            opts.Scratch,
+		RunDirBase:         opts.RunDirBase,
+		CleanupRunDir:      opts.CleanupRunDir,
+		PTY:                opts.PTY,
+		MaxLoad:            opts.MaxLoad,
+		WorkingDays:        opts.WorkingDays,
+		Output:             "passthrough",
+		LogDir:             cfg.logDir,
+		CaptureOutput:      opts.CaptureOutput,
+		LogLevel:           cfg.level,
+		LogFormat:          cfg.logFormat,
+		LogSinks:           cfg.logSinks,
+		Quiet:              cfg.quiet,
+		Notifiers:          []string{},
+		NotifyOnRecovery:   cfg.notifyOnRecovery,
+		FailureThreshold:   cfg.failureThreshold,
+		PublishCommand:     cfg.publishCommand,
+		DailyBudget:        cfg.dailyBudget.String(),
+		StateFile:          cfg.stateFile,
+		ProbeCommand:       cfg.probeCommand,
+		HTTPAddr:           cfg.httpAddr,
+		HistoryExport:      cfg.historyExport,
+		ReportInterval:     cfg.reportInterval.String(),
+		ExitOnIdle:         cfg.exitOnIdle.String(),
+		MaxLifetime:        cfg.maxLifetime.String(),
+		SingletonPort:      cfg.singletonPort,
+		WaitFor:            cfg.waitForAddrs,
+		SdNotify:           cfg.sdNotifyFlag,
+		RestartOnChange:    cfg.restartOnConfigChange,
+		AbortOnSigint:      cfg.abortOnSigint,
+	}
+	if ec.SuccessCodes == nil {
+		ec.SuccessCodes = []int{0}
+	}
+	for _, kv := range opts.Env {
+		key, _, _ := strings.Cut(kv, "=")
+		ec.Env = append(ec.Env, key+"="+redacted)
+	}
+	if a := opts.Adaptive; a != nil {
+		ec.Adaptive = &adaptiveConfig{NoopCode: a.NoopCode, Min: a.Min.String(), Max: a.Max.String()}
+	}
+	if opts.ExpectOutput != nil {
+		ec.ExpectOutput = opts.ExpectOutput.String()
+	}
+	if opts.FailOutput != nil {
+		ec.FailOutput = opts.FailOutput.String()
+	}
+	for day := range opts.Holidays {
+		ec.Holidays = append(ec.Holidays, day)
+	}
+	slices.Sort(ec.Holidays)
+	switch {
+	case cfg.logDir != "":
+		ec.Output = "log-dir"
+	case cfg.logOutputLines:
+		ec.Output = "log-output"
+	case cfg.logOutputOnFailure:
+		ec.Output = "log-output-on-failure"
+	}
+
+	// Mirrors the notifier selection in buildOptions.
+	specs := cfg.notifierSpecs
+	if len(specs) == 0 && cfg.slackWebhook != "" {
+		specs = stringList{"slack"}
+	}
+	for _, spec := range specs {
+		if strings.HasPrefix(spec, "webhook=") {
+			ec.Notifiers = append(ec.Notifiers, "webhook="+redacted)
+			ec.NotifyEvents = cfg.notifyEvents
+			continue
+		}
+		if spec == "slack" {
+			ec.SlackOn = cfg.slackOn
+		}
+		ec.Notifiers = append(ec.Notifiers, spec)
+	}
+	if cfg.admissionURL != "" {
+		ec.AdmissionWebhook = redactURL(cfg.admissionURL)
+	}
+	if cfg.stdinURL != "" {
+		ec.StdinURL = redactURL(cfg.stdinURL)
+	}
+	return ec, nil
+}
+
+// dumpConfig prints the configuration a run with cfg and positionals
+// would use as JSON.
+func dumpConfig(cfg *config, positionals []string) error {
+	ec, err := newEffectiveConfig(cfg, positionals)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(ec)
+}
@@ -9,16 +9,21 @@
 package main
 
 import (
-	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log/slog"
+	"net"
+	"net/http"
 	"os"
-	"os/exec"
 	"os/signal"
-	"sync"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"syscall"
+	"time"
 
-	"github.com/robfig/cron/v3"
+	"github.com/focela/cronx/pkg/runner"
 )
 
 var (
@@ -31,70 +36,190 @@ var (
 	// builtBy is set by ldflags during build.
 	builtBy = "unknown"
 
+	// logLevel controls the minimum level emitted by logger.
+	logLevel = new(slog.LevelVar)
+
+	// logOutput is stdout, falling back to stderr if stdout is a broken pipe.
+	logOutput = &fallbackWriter{w: os.Stdout, fallback: os.Stderr}
+
+	// flushLogs writes out any batched log records.
+	flushLogs = func() {}
+
 	// logger provides structured logging throughout the application.
-	logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
+	logger = slog.New(slog.NewJSONHandler(logOutput, &slog.HandlerOptions{
+		Level: logLevel,
 	}))
 )
 
 const (
-	minArgs = 3
+	minArgs = 2
+	// recentFailures is how many failed runs a state dump reports.
+	recentFailures = 10
+	// lifetimeRetry is how often --max-lifetime rechecks for running jobs.
+	lifetimeRetry = time.Second
+	// escalateAfter is how soon after the first shutdown signal a second
+	// one is taken for the same delivery, as when a supervisor signals the
+	// process and its group back to back. Any later repeat, however quick
+	// a double Ctrl-C, kills running jobs.
+	escalateAfter = 10 * time.Millisecond
 )
 
-// execute runs command with args, redirecting stdout/stderr.
-func execute(command string, args []string) error {
-	logger.Info("executing command", "command", command, "args", args)
-
-	cmd := exec.Command(command, args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+// parseSuccessCodes parses a comma-separated list of exit codes.
+func parseSuccessCodes(s string) ([]int, error) {
+	var codes []int
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		code, err := strconv.Atoi(field)
+		if err != nil || code < 0 || code > 255 {
+			return nil, fmt.Errorf("invalid exit code '%s'", field)
+		}
+		codes = append(codes, code)
+	}
+	if len(codes) == 0 {
+		return nil, errors.New("no exit codes given")
+	}
+	return codes, nil
+}
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("command execution failed: %w", err)
+// parseCPUList parses a comma-separated list of CPU numbers. Whether
+// they are available is checked by runner.New.
+func parseCPUList(s string) ([]int, error) {
+	var cpus []int
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		cpu, err := strconv.Atoi(field)
+		if err != nil || cpu < 0 {
+			return nil, fmt.Errorf("invalid CPU '%s'", field)
+		}
+		cpus = append(cpus, cpu)
 	}
-	return nil
+	return cpus, nil
 }
 
-// create initializes cron scheduler that respects ctx cancellation.
-func create(ctx context.Context, schedule string, command string, args []string) (*cron.Cron, *sync.WaitGroup, error) {
-	wg := &sync.WaitGroup{}
+// signalName returns the conventional name of sig, e.g. "SIGTERM".
+func signalName(sig os.Signal) string {
+	for name, s := range signalNames {
+		if s == sig {
+			return "SIG" + name
+		}
+	}
+	return sig.String()
+}
 
-	// Supports optional seconds and descriptors (@daily, @weekly).
-	parser := cron.NewParser(
-		cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
-	)
+// signalAction is what cronx does when it receives a signal.
+type signalAction struct {
+	signal os.Signal
+	// action is "drain", "abort", "restart" or "dump".
+	action string
+	// state is the scheduler state the action leads to.
+	state string
+}
 
-	if _, err := parser.Parse(schedule); err != nil {
-		return nil, nil, fmt.Errorf("invalid schedule '%s': %w", schedule, err)
+// parseSignal resolves a signal name such as "SIGUSR1" or "usr1".
+func parseSignal(name string) (syscall.Signal, error) {
+	sig, ok := signalNames[strings.TrimPrefix(strings.ToUpper(name), "SIG")]
+	if !ok {
+		return 0, fmt.Errorf("unknown signal '%s'", name)
 	}
+	return sig, nil
+}
 
-	c := cron.New(cron.WithParser(parser))
-	logger.Info("new cron scheduled", "schedule", schedule)
+// scheduler bundles a runner with the resources the CLI attaches to it.
+type scheduler struct {
+	runner *runner.Runner
+	// http serves the status endpoints when non-nil.
+	http *http.Server
+	// singleton holds the --singleton-port listener when non-nil.
+	singleton net.Listener
+	// report logs --report-interval summaries when non-nil.
+	report *reporter
+	// export is written to --history-export on shutdown when non-nil.
+	export *runReport
+	// publish is flushed on shutdown when non-nil.
+	publish *publisher
+	// hist backs the status endpoints when non-nil.
+	hist *history
+	// failures keeps the most recent failed runs for state dumps.
+	failures *history
+	// skips counts skipped runs by reason.
+	skips *skipCounter
+	// liveness runs --probe-command when non-nil.
+	liveness *probe
+	// jobOutput is the --log-dir job log when non-nil.
+	jobOutput *jobLog
+	// idle expires after --exit-on-idle without a run when non-nil.
+	idle *time.Timer
+}
 
-	c.AddFunc(schedule, func() {
-		wg.Add(1)
-		defer wg.Done()
+// exitConfigError logs err, returned while setting up a run, and exits.
+func exitConfigError(err error) {
+	var ce *configError
+	if errors.As(err, &ce) {
+		logger.Error(ce.msg, "error", ce.err)
+	} else {
+		logger.Error("invalid configuration", "error", err)
+	}
+	os.Exit(1)
+}
 
-		select {
-		case <-ctx.Done():
-			return
-		default:
-			if err := execute(command, args); err != nil {
-				logger.Error("command execution error", "error", err)
-			}
+// stop shuts down scheduler and waits for running jobs to complete.
+func stop(s *scheduler) {
+	if s.report != nil {
+		s.report.stop()
+	}
+	s.runner.Stop()
+	if s.publish != nil {
+		s.publish.close()
+	}
+	if s.http != nil {
+		if err := s.http.Close(); err != nil {
+			logger.Error("failed to close http server", "error", err)
 		}
-	})
+	}
+	if s.singleton != nil {
+		s.singleton.Close()
+	}
+	if s.export != nil {
+		if err := s.export.write(); err != nil {
+			logger.Error("failed to export run history", "path", s.export.path, "error", err)
+		} else {
+			logger.InfoContext(lifecycle, "exported run history", "path", s.export.path)
+		}
+	}
+	logger.InfoContext(lifecycle, "scheduler stopped successfully")
+}
 
-	return c, wg, nil
+// dumpState logs the running jobs, the next fire time and recent
+// failures without affecting the scheduler.
+func dumpState(s *scheduler, failures *history) {
+	running := s.runner.Running()
+	logger.InfoContext(lifecycle, "state dump", "running", len(running), "next_run", s.runner.Next())
+	for _, run := range running {
+		logger.InfoContext(lifecycle, "running job",
+			"run_id", run.ID,
+			"scheduled", run.Scheduled,
+			"started", run.Started,
+			"elapsed", time.Since(run.Started).String(),
+		)
+	}
+	for _, rec := range failures.list() {
+		logger.InfoContext(lifecycle, "recent failure",
+			"scheduled", rec.Scheduled,
+			"exit_code", rec.ExitCode,
+			"error", rec.Error,
+		)
+	}
 }
 
-// stop shuts down scheduler and waits for running jobs to complete.
-func stop(c *cron.Cron, wg *sync.WaitGroup) {
-	logger.Info("stopping scheduler")
-	c.Stop()
-	logger.Info("waiting for running jobs to complete")
-	wg.Wait()
-	logger.Info("scheduler stopped successfully")
+// exit flushes batched logs, reports a Windows service as stopped and
+// terminates the process with code.
+func exit(code int) {
+	flushLogs()
+	stopService(code)
+	os.Exit(code)
 }
 
 // showVersion displays version information to stdout.
@@ -105,40 +230,405 @@ func showVersion() {
 	fmt.Printf("built by: %s\n", builtBy)
 }
 
+// loadLocation resolves the --tz and --utc flags, defaulting to local time.
+func loadLocation(tz string, utc bool) (*time.Location, error) {
+	if utc {
+		if tz != "" {
+			return nil, errors.New("--utc and --tz are mutually exclusive")
+		}
+		return time.UTC, nil
+	}
+	if tz == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone '%s': %w", tz, err)
+	}
+	return loc, nil
+}
+
+// printSchedule prints the next fire times of a schedule without running anything.
+func printSchedule(args []string) error {
+	fs := flag.NewFlagSet("schedule", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cronx schedule [flags] [schedule]")
+		fs.PrintDefaults()
+	}
+	count := fs.Int("count", 10, "number of fire times to print")
+	tz := fs.String("tz", "", "time zone the schedule is evaluated and displayed in (default local)")
+	utc := fs.Bool("utc", false, "evaluate and display the schedule in UTC; shortcut for --tz UTC")
+	syntax := fs.String("cron-syntax", "optional-seconds", "schedule syntax: optional-seconds, standard, with-seconds or quartz (with-seconds that rejects Quartz-only fields)")
+	offset := fs.Duration("schedule-offset", 0, "shift every fire time by this duration (may be negative)")
+	align := fs.Bool("align-first-run", false, "snap the first run of an @every schedule to the next multiple of its interval")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *count < 1 {
+		return errors.New("--count must be at least 1")
+	}
+
+	loc, err := loadLocation(*tz, *utc)
+	if err != nil {
+		return err
+	}
+	sched, err := runner.Parse(fs.Arg(0), *syntax)
+	if err != nil {
+		return err
+	}
+	if *align {
+		sched = runner.AlignFirst(sched, slog.New(slog.DiscardHandler))
+	}
+	sched = runner.WithOffset(sched, *offset)
+
+	t := time.Now().In(loc)
+	for range *count {
+		t = sched.Next(t)
+		if t.IsZero() {
+			break
+		}
+		fmt.Println(t.Format(time.RFC3339))
+	}
+	return nil
+}
+
 // main parses arguments and runs cron scheduler with signal handling.
 func main() {
+	// Report writes to a closed stdout as EPIPE instead of dying on SIGPIPE.
+	// Notify rather than Ignore so children don't inherit SIG_IGN.
+	signal.Notify(make(chan os.Signal, 1), syscall.SIGPIPE)
+	logOutput.onBroken = func() { logger.Warn("stdout is a broken pipe, logging to stderr") }
+
 	if len(os.Args) >= 2 && os.Args[1] == "version" {
 		showVersion()
 		return
 	}
+	if len(os.Args) >= 2 && os.Args[1] == "selftest" {
+		if !selftest() {
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) >= 2 && os.Args[1] == "schedule" {
+		if err := printSchedule(os.Args[2:]); err != nil {
+			logger.Error("failed to print schedule", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) >= 2 && os.Args[1] == "backfill" {
+		if err := backfill(os.Args[2:]); err != nil {
+			logger.Error("backfill failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	if len(os.Args) < minArgs {
-		fmt.Println("Usage: cronx [schedule] [command] [args ...]")
-		fmt.Println("       cronx version")
+	if len(os.Args) >= 2 && os.Args[1] == "service" {
+		proceed, err := service(os.Args[2:])
+		if err != nil {
+			logger.Error("service command failed", "error", err)
+			os.Exit(1)
+		}
+		if !proceed {
+			return
+		}
+	}
+
+	// dump-config takes the same flags as a normal run.
+	dump := len(os.Args) >= 2 && os.Args[1] == "dump-config"
+
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: cronx [flags] [schedule] [command] [args ...]")
+		fmt.Fprintln(flag.CommandLine.Output(), "       cronx schedule [--count N] [--tz zone] [schedule]")
+		fmt.Fprintln(flag.CommandLine.Output(), "       cronx backfill --schedule spec --from time --to time [flags] [--] command [args ...]")
+		fmt.Fprintln(flag.CommandLine.Output(), "       cronx dump-config [flags] [schedule] [command] [args ...]")
+		fmt.Fprintln(flag.CommandLine.Output(), "       cronx service install|run [flags] [schedule] [command] [args ...]")
+		fmt.Fprintln(flag.CommandLine.Output(), "       cronx service uninstall")
+		fmt.Fprintln(flag.CommandLine.Output(), "       cronx selftest")
+		fmt.Fprintln(flag.CommandLine.Output(), "       cronx version")
+		flag.PrintDefaults()
+	}
+	cfg := defineFlags(flag.CommandLine)
+	args := os.Args[1:]
+	if dump {
+		args = os.Args[2:]
+	}
+	if err := parseFlags(flag.CommandLine, args); err != nil {
+		var unknown *unknownFlagError
+		switch {
+		case errors.Is(err, flag.ErrHelp):
+			flag.Usage()
+			os.Exit(0)
+		case errors.As(err, &unknown):
+			attrs := []any{"flag", "--" + unknown.name}
+			if unknown.suggestion != "" {
+				attrs = append(attrs, "suggestion", "--"+unknown.suggestion)
+			}
+			logger.Error("unknown flag", attrs...)
+		default:
+			logger.Error("invalid flags", "error", err)
+		}
+		os.Exit(2)
+	}
+
+	// Positional arguments win; the environment is for deployments that
+	// cannot pass any.
+	positionals := flag.Args()
+	if len(positionals) == 0 {
+		var err error
+		if positionals, err = envPositionals(); err != nil {
+			logger.Error("invalid environment", "error", err)
+			os.Exit(1)
+		}
+	}
+	if len(positionals) < minArgs {
+		flag.Usage()
 		os.Exit(1)
 	}
+	// Logging is left alone so that stdout carries nothing but the JSON.
+	if dump {
+		if err := dumpConfig(cfg, positionals); err != nil {
+			var ce *configError
+			if errors.As(err, &ce) {
+				exitConfigError(err)
+			}
+			logger.Error("failed to dump config", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	schedule := os.Args[1]
-	command := os.Args[2]
-	args := os.Args[3:]
+	if err := configureLogging(cfg); err != nil {
+		exitConfigError(err)
+	}
+	if err := validate(cfg); err != nil {
+		exitConfigError(err)
+	}
+	opts, s, err := buildOptions(cfg, positionals)
+	if err != nil {
+		exitConfigError(err)
+	}
+	if cfg.utc {
+		logger.Info("scheduling in UTC")
+	}
+	schedule, command := opts.Schedule, opts.Command
+	if size := argvSize(command, opts.Args, append(runner.InheritedEnv(opts.EnvPassthrough), opts.Env...)); size > argMax {
+		logger.Warn("command line may exceed the system argument limit", "size", size, "limit", argMax)
+	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	if cfg.singletonPort != 0 {
+		addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(cfg.singletonPort))
+		if s.singleton, err = net.Listen("tcp", addr); err != nil {
+			logger.Error("another instance is running", "mechanism", "singleton-port", "addr", addr, "error", err)
+			os.Exit(1)
+		}
+		logger.Info("acquired singleton lock", "mechanism", "singleton-port", "addr", addr)
+	}
 
-	c, wg, err := create(ctx, schedule, command, args)
+	var idleC <-chan time.Time
+	if s.idle != nil {
+		idleC = s.idle.C
+	}
+	var lifetime *time.Timer
+	var lifetimeC <-chan time.Time
+	lifetimeWaiting := false
+	if cfg.maxLifetime > 0 {
+		lifetime = time.NewTimer(cfg.maxLifetime)
+		lifetimeC = lifetime.C
+	}
+
+	r, err := runner.New(opts)
 	if err != nil {
 		logger.Error("failed to create scheduler", "error", err)
 		os.Exit(1)
 	}
 
-	c.Start()
+	s.runner = r
+	if cfg.httpAddr != "" {
+		if s.http, err = startHTTPServer(cfg.httpAddr, s.hist, s.skips, r, schedule); err != nil {
+			logger.Error("failed to start http server", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	if len(cfg.waitForAddrs) > 0 {
+		if err := waitFor(cfg.waitForAddrs, cfg.waitTimeout); err != nil {
+			logger.Error("dependencies not ready", "error", err)
+			exit(1)
+		}
+	}
 
+	r.Start()
+	logger.InfoContext(lifecycle, "cronx started", "version", version, "schedule", schedule, "command", command)
+	if s.liveness != nil {
+		go s.liveness.run()
+	}
+	if s.report != nil {
+		s.report.runner = r
+		go s.report.run()
+	}
+	if cfg.sdNotifyFlag {
+		sdNotify("READY=1")
+		go sdWatchdog()
+	}
+
+	actions := []signalAction{
+		{syscall.SIGINT, "drain", "draining"},
+		{syscall.SIGTERM, "drain", "draining"},
+	}
+	if cfg.abortOnSigint {
+		actions[0] = signalAction{syscall.SIGINT, "abort", "aborting"}
+	}
+	// A restart reopens the job log as well, so it takes precedence.
+	var reopenSignal os.Signal
+	if cfg.restartOnConfigChange {
+		actions = append(actions, signalAction{syscall.SIGHUP, "restart", "restarting"})
+	} else if s.jobOutput != nil {
+		reopenSignal = syscall.SIGHUP
+		actions = append(actions, signalAction{reopenSignal, "reopen", "running"})
+	}
+	if dumpSignal != nil {
+		actions = append(actions, signalAction{dumpSignal, "dump", "running"})
+	}
+	var signals []os.Signal
+	var handlers []any
+	for _, a := range actions {
+		signals = append(signals, a.signal)
+		handlers = append(handlers, signalName(a.signal), a.action)
+	}
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	sig := <-sigChan
-	logger.Info("received signal", "signal", sig)
+	signal.Notify(sigChan, signals...)
+	if serviceStop != nil {
+		go func() {
+			for sig := range serviceStop {
+				sigChan <- sig
+			}
+		}()
+	}
+	logger.Info("signal handlers", handlers...)
+
+	var sig os.Signal
+	var state string
+	var received time.Time
+	for sig == nil {
+		select {
+		case sig = <-sigChan:
+			received = time.Now()
+			for _, a := range actions {
+				if a.signal == sig {
+					logger.InfoContext(lifecycle, "received signal",
+						"signal", signalName(sig),
+						"action", a.action,
+						"transition", "running -> "+a.state,
+					)
+					state = a.state
+				}
+			}
+			if sig == dumpSignal {
+				dumpState(s, s.failures)
+				sig = nil
+			}
+			if sig != nil && sig == reopenSignal {
+				s.jobOutput.reopen()
+				sig = nil
+			}
+		case <-idleC:
+			// A run longer than the idle window is still activity.
+			if len(r.Running()) > 0 {
+				s.idle.Reset(cfg.exitOnIdle)
+				continue
+			}
+			logger.InfoContext(lifecycle, "shutting down after idle timeout", "idle", cfg.exitOnIdle.String())
+			if cfg.sdNotifyFlag {
+				sdNotify("STOPPING=1")
+			}
+			stop(s)
+			exit(0)
+		case <-lifetimeC:
+			// Restart between runs rather than interrupting one.
+			if running := len(r.Running()); running > 0 {
+				if !lifetimeWaiting {
+					logger.Info("max lifetime reached, waiting for running jobs", "running", running)
+					lifetimeWaiting = true
+				}
+				lifetime.Reset(lifetimeRetry)
+				continue
+			}
+			logger.InfoContext(lifecycle, "restarting after max lifetime", "max_lifetime", cfg.maxLifetime.String())
+			if cfg.sdNotifyFlag {
+				sdNotify("STOPPING=1")
+			}
+			stop(s)
+			flushLogs()
+			if err := reexec(); err != nil {
+				logger.Error("failed to restart", "error", err)
+				exit(1)
+			}
+		}
+	}
+	if cfg.sdNotifyFlag {
+		sdNotify("STOPPING=1")
+	}
 
-	cancel()
-	stop(c, wg)
-	os.Exit(0)
+	// The first signal shuts down gracefully; a second SIGINT or SIGTERM
+	// escapes a stuck drain by killing the running jobs. Supervisors such
+	// as timeout(1) signal both the process and its group, so a repeat
+	// right after the first signal is the same request, not an escalation.
+	var forced atomic.Bool
+	go func() {
+		for next := range sigChan {
+			if next == dumpSignal {
+				dumpState(s, s.failures)
+				continue
+			}
+			if next == reopenSignal {
+				s.jobOutput.reopen()
+				continue
+			}
+			if next != syscall.SIGINT && next != syscall.SIGTERM {
+				continue
+			}
+			if time.Since(received) < escalateAfter {
+				logger.Info("ignoring repeated signal", "signal", signalName(next), "within", escalateAfter.String())
+				continue
+			}
+			logger.WarnContext(lifecycle, "received second signal, killing running jobs",
+				"signal", signalName(next),
+				"transition", state+" -> aborting",
+			)
+			forced.Store(true)
+			r.Kill()
+			return
+		}
+	}()
+
+	switch {
+	case sig == syscall.SIGINT && cfg.abortOnSigint:
+		logger.Info("aborting running jobs", "signal", signalName(sig))
+		r.Kill()
+		stop(s)
+		exit(1)
+	case sig == syscall.SIGHUP:
+		logger.InfoContext(lifecycle, "restarting on config change", "signal", signalName(sig))
+		stop(s)
+		if forced.Load() {
+			exit(1)
+		}
+		flushLogs()
+		if err := reexec(); err != nil {
+			logger.Error("failed to restart", "error", err)
+			exit(1)
+		}
+	}
+	logger.Info("draining running jobs", "signal", signalName(sig))
+	stop(s)
+	if forced.Load() {
+		exit(1)
+	}
+	exit(0)
 }
@@ -0,0 +1,130 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+// mainEnv makes the test binary run main instead of the tests, so that a
+// test can start cronx as a child process with its own arguments.
+const mainEnv = "CRONX_TEST_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(mainEnv) == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// cronxCommand returns a command that runs cronx with args.
+func cronxCommand(args ...string) *exec.Cmd {
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), mainEnv+"=1")
+	return cmd
+}
+
+// cronxProcess is cronx running as a child of the test.
+type cronxProcess struct {
+	cmd *exec.Cmd
+	// records receives each JSON log record cronx writes, and is closed
+	// when its stdout is.
+	records chan map[string]any
+	// seen holds the messages of the records read so far.
+	seen []string
+}
+
+// startCronx starts cronx with args.
+func startCronx(t *testing.T, args ...string) *cronxProcess {
+	t.Helper()
+	cmd := cronxCommand(args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	p := &cronxProcess{cmd: cmd, records: make(chan map[string]any, 64)}
+	go func() {
+		defer close(p.records)
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			// The command's own output is passed through unstructured.
+			var record map[string]any
+			if json.Unmarshal(scanner.Bytes(), &record) == nil {
+				p.records <- record
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		if cmd.ProcessState == nil {
+			cmd.Process.Kill()
+			cmd.Wait()
+		}
+	})
+	return p
+}
+
+// waitForLog reads records until one has msg and returns it.
+func (p *cronxProcess) waitForLog(t *testing.T, msg string) map[string]any {
+	t.Helper()
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case record, ok := <-p.records:
+			if !ok {
+				t.Fatalf("cronx exited without logging %q; logged %q", msg, p.seen)
+			}
+			got, _ := record["msg"].(string)
+			p.seen = append(p.seen, got)
+			if got == msg {
+				return record
+			}
+		case <-timeout:
+			t.Fatalf("cronx did not log %q; logged %q", msg, p.seen)
+		}
+	}
+}
+
+// wait waits for cronx to exit, reading the rest of its log, and returns
+// its exit code.
+func (p *cronxProcess) wait(t *testing.T) int {
+	t.Helper()
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case record, ok := <-p.records:
+			if ok {
+				got, _ := record["msg"].(string)
+				p.seen = append(p.seen, got)
+				continue
+			}
+			p.cmd.Wait()
+			return p.cmd.ProcessState.ExitCode()
+		case <-timeout:
+			t.Fatalf("cronx did not exit; logged %q", p.seen)
+		}
+	}
+}
+
+// logged reports whether cronx has logged a record with msg.
+func (p *cronxProcess) logged(msg string) bool {
+	for _, got := range p.seen {
+		if got == msg {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// printArgs is a command that writes each of its arguments on a line of
+// $CRONX_TEST_OUT.
+var printArgs = []string{"sh", "-c", `printf '%s\n' "$@" > "$CRONX_TEST_OUT.tmp" && mv "$CRONX_TEST_OUT.tmp" "$CRONX_TEST_OUT"`, "sh"}
+
+func TestCommandKeepsItsFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		// flags come before the schedule and command, args after them.
+		flags []string
+		args  []string
+	}{
+		{name: "short and long flags", args: []string{"-la", "--color=auto"}},
+		{name: "flag named like a cronx flag", flags: []string{"--jitter", "0s"}, args: []string{"--jitter", "5s"}},
+		{name: "double dash", flags: []string{"--"}, args: []string{"--", "-v"}},
+		{name: "single dash", args: []string{"-czf", "-", "/data"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			out := filepath.Join(t.TempDir(), "args")
+			argv := append(slices.Clone(tt.flags), "@every 1s")
+			argv = append(argv, printArgs...)
+			cmd := cronxCommand(append(argv, tt.args...)...)
+			cmd.Env = append(cmd.Env, "CRONX_TEST_OUT="+out)
+			if err := cmd.Start(); err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				cmd.Process.Kill()
+				cmd.Wait()
+			}()
+
+			deadline := time.Now().Add(10 * time.Second)
+			for {
+				data, err := os.ReadFile(out)
+				if err == nil {
+					got := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+					if !slices.Equal(got, tt.args) {
+						t.Errorf("command got %q, want %q", got, tt.args)
+					}
+					return
+				}
+				if time.Now().After(deadline) {
+					t.Fatal("command did not run")
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		})
+	}
+}
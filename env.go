@@ -0,0 +1,92 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+// String implements flag.Value.
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set implements flag.Value.
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// validateEnvVar checks that s is a KEY=VALUE assignment.
+func validateEnvVar(s string) error {
+	key, _, ok := strings.Cut(s, "=")
+	if !ok || strings.TrimSpace(key) == "" {
+		return fmt.Errorf("invalid environment variable '%s': expected KEY=VALUE", s)
+	}
+	return nil
+}
+
+// loadEnvFile reads KEY=VALUE lines from path, skipping blank lines and
+// comments. Values may be wrapped in single or double quotes.
+func loadEnvFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open env file: %w", err)
+	}
+	defer f.Close()
+
+	var env []string
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE, got '%s'", path, n, line)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		env = append(env, key+"="+value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+	return env, nil
+}
+
+// envPositionals returns the schedule, command and arguments from
+// CRONX_SCHEDULE, CRONX_COMMAND and CRONX_ARGS, or nil if CRONX_SCHEDULE
+// is unset. CRONX_ARGS is split into words like a POSIX shell would.
+func envPositionals() ([]string, error) {
+	schedule := os.Getenv("CRONX_SCHEDULE")
+	if schedule == "" {
+		return nil, nil
+	}
+	command := os.Getenv("CRONX_COMMAND")
+	if command == "" {
+		return nil, errors.New("CRONX_SCHEDULE is set but CRONX_COMMAND is not")
+	}
+	args, err := splitWords(os.Getenv("CRONX_ARGS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRONX_ARGS: %w", err)
+	}
+	return append([]string{schedule, command}, args...), nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// argMax is the smallest common limit on the combined size of argv and
+// the environment (1 MiB on macOS; Linux defaults to 2 MiB).
+const argMax = 1 << 20
+
+// argvSize estimates how much of argMax command and args use when
+// started with env. Each string costs its bytes, a NUL and a pointer.
+func argvSize(command string, args, env []string) int {
+	const ptr = strconv.IntSize / 8
+	n := len(command) + 1 + ptr
+	for _, s := range args {
+		n += len(s) + 1 + ptr
+	}
+	for _, s := range env {
+		n += len(s) + 1 + ptr
+	}
+	return n
+}
+
+// reexec replaces the current process with a fresh copy of cronx
+// started with the same arguments and environment.
+func reexec() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to resolve executable: %w", err)
+	}
+	if err := syscall.Exec(exe, os.Args, os.Environ()); err != nil {
+		return fmt.Errorf("failed to re-exec %s: %w", exe, err)
+	}
+	return nil
+}
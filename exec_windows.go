@@ -0,0 +1,30 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+//go:build windows
+
+package main
+
+import "errors"
+
+// argMax is the maximum length of a Windows command line.
+const argMax = 32767
+
+// argvSize estimates how much of argMax command and args use. The
+// environment does not count towards the command line on Windows.
+func argvSize(command string, args, env []string) int {
+	n := len(command)
+	for _, s := range args {
+		n += len(s) + 3 // separator and quotes
+	}
+	return n
+}
+
+// reexec is not supported on Windows, which lacks exec(2).
+func reexec() error {
+	return errors.New("re-exec is not supported on windows")
+}
@@ -0,0 +1,118 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxReportRecords bounds the runs kept for --history-export, so that a
+// frequent job running for months does not grow without limit.
+const maxReportRecords = 100000
+
+// runReport keeps the runs since startup for --history-export, up to
+// maxReportRecords of the most recent ones.
+type runReport struct {
+	path string
+
+	mu      sync.Mutex
+	records []runRecord
+	// next is where the following run goes once records is full.
+	next int
+	// dropped counts the oldest runs overwritten to stay within the limit.
+	dropped int
+}
+
+// newRunReport returns a report written to path, whose extension selects
+// CSV or JSON.
+func newRunReport(path string) (*runReport, error) {
+	switch filepath.Ext(path) {
+	case ".csv", ".json":
+		return &runReport{path: path}, nil
+	}
+	return nil, fmt.Errorf("unsupported extension '%s': expected .csv or .json", filepath.Ext(path))
+}
+
+// add appends a finished run without its output tail, which is the bulk
+// of a record, overwriting the oldest run once the report is full.
+func (rp *runReport) add(r runRecord) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	r.OutputTail = ""
+	if len(rp.records) < maxReportRecords {
+		rp.records = append(rp.records, r)
+		return
+	}
+	rp.records[rp.next] = r
+	rp.next = (rp.next + 1) % maxReportRecords
+	rp.dropped++
+}
+
+// write replaces the report file with every run kept so far.
+func (rp *runReport) write() error {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	records := append(append([]runRecord(nil), rp.records[rp.next:]...), rp.records[:rp.next]...)
+	if rp.dropped > 0 {
+		logger.Warn("history export left out the oldest runs", "dropped", rp.dropped, "kept", len(records))
+	}
+
+	f, err := os.Create(rp.path)
+	if err != nil {
+		return err
+	}
+	if filepath.Ext(rp.path) == ".csv" {
+		err = writeCSVReport(f, records)
+	} else {
+		err = writeJSONReport(f, records)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// writeJSONReport writes records as an indented JSON array.
+func writeJSONReport(w io.Writer, records []runRecord) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if records == nil {
+		records = []runRecord{}
+	}
+	return enc.Encode(records)
+}
+
+// writeCSVReport writes records as CSV with a header row. Output is left
+// out since it rarely fits a spreadsheet cell.
+func writeCSVReport(w io.Writer, records []runRecord) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"run_id", "scheduled", "started", "duration", "exit_code", "success", "error"})
+	for _, r := range records {
+		cw.Write([]string{
+			r.RunID,
+			r.Scheduled.Format(time.RFC3339Nano),
+			r.Started.Format(time.RFC3339Nano),
+			r.Duration,
+			strconv.Itoa(r.ExitCode),
+			strconv.FormatBool(r.Success),
+			r.Error,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,57 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestRunReportKeepsMostRecentRuns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs.json")
+	rp, err := newRunReport(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const extra = 5
+	for i := range maxReportRecords + extra {
+		rp.add(runRecord{RunID: strconv.Itoa(i), OutputTail: "some output"})
+	}
+	if err := rp.write(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []runRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != maxReportRecords {
+		t.Fatalf("report has %d runs, want %d", len(records), maxReportRecords)
+	}
+	if first, last := records[0].RunID, records[len(records)-1].RunID; first != strconv.Itoa(extra) || last != strconv.Itoa(maxReportRecords+extra-1) {
+		t.Errorf("report spans runs %s to %s, want %d to %d", first, last, extra, maxReportRecords+extra-1)
+	}
+	for _, r := range records {
+		if r.OutputTail != "" {
+			t.Fatalf("run %s kept its output tail", r.RunID)
+		}
+	}
+}
+
+func TestNewRunReportRejectsExtension(t *testing.T) {
+	if _, err := newRunReport("runs.txt"); err == nil {
+		t.Error("newRunReport accepted a .txt path")
+	}
+}
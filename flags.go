@@ -0,0 +1,261 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"strings"
+	"time"
+)
+
+// maxSuggestDistance is the largest edit distance a flag suggestion may have.
+const maxSuggestDistance = 2
+
+// config holds the flags of a normal run. Fields are named after the
+// flags they hold.
+type config struct {
+	level                 string
+	logFormat             string
+	quiet                 bool
+	logIncludeHost        bool
+	logFlushInterval      time.Duration
+	logSinks              stringList
+	logDedup              time.Duration
+	description           string
+	syntax                string
+	scheduleOffset        time.Duration
+	alignFirstRun         bool
+	tz                    string
+	utc                   bool
+	dailyBudget           time.Duration
+	stateFile             string
+	maxLoad               float64
+	workingSchedule       bool
+	holidaysFile          string
+	successCodes          string
+	adaptiveInterval      bool
+	noopExitCode          int
+	minInterval           time.Duration
+	maxInterval           time.Duration
+	expectOutput          string
+	failOutput            string
+	stderrIsFailure       bool
+	jitter                time.Duration
+	jitterSeed            uint64
+	restartOnConfigChange bool
+	abortOnSigint         bool
+	envVars               stringList
+	expandEnv             bool
+	expandUndefined       string
+	envPassthrough        string
+	envFile               string
+	notifierSpecs         stringList
+	notifyEvents          string
+	publishCommand        string
+	publishTimeout        time.Duration
+	slackWebhook          string
+	slackOn               string
+	slackTemplate         string
+	waitForAddrs          stringList
+	waitTimeout           time.Duration
+	probeCommand          string
+	probeInterval         time.Duration
+	probeFailures         int
+	reportInterval        time.Duration
+	maxLifetime           time.Duration
+	exitOnIdle            time.Duration
+	sdNotifyFlag          bool
+	failureThreshold      int
+	notifyOnRecovery      bool
+	admissionURL          string
+	admissionTimeout      time.Duration
+	admissionFailOpen     bool
+	stdinURL              string
+	stdinTimeout          time.Duration
+	stdinOnError          string
+	singletonPort         int
+	httpAddr              string
+	historySize           int
+	historyExport         string
+	commandAllowlist      string
+	argsFile              string
+	wrapper               string
+	cwdPerRun             string
+	cleanupCwd            bool
+	logOutputOnFailure    bool
+	logDir                string
+	logOutputLines        bool
+	stdoutLevel           string
+	stderrLevel           string
+	parseJSONOutput       bool
+	cpuAffinity           string
+	pty                   bool
+	chroot                string
+	scratch               bool
+	timeoutPercent        int
+	outputStallTimeout    time.Duration
+	timeoutAsSuccess      bool
+	timeoutGrace          time.Duration
+	timeoutWarnSignal     string
+}
+
+// defineFlags defines the flags of a normal run on fs and returns the
+// config they parse into.
+func defineFlags(fs *flag.FlagSet) *config {
+	cfg := &config{}
+	fs.StringVar(&cfg.level, "log-level", "info", "minimum log level: debug, info, warn or error")
+	fs.StringVar(&cfg.logFormat, "log-format", "json", "format of the default stdout log: json, text or logfmt")
+	fs.BoolVar(&cfg.quiet, "quiet", false, "only log warnings, errors, and startup/shutdown records")
+	fs.BoolVar(&cfg.logIncludeHost, "log-include-host", false, "add host and pid fields to every log record")
+	fs.DurationVar(&cfg.logFlushInterval, "log-flush-interval", 0, "batch log writes and flush them at this interval (0 writes immediately)")
+	fs.Var(&cfg.logSinks, "log-sink", "log destination as stdout:FORMAT[:LEVEL], stderr:FORMAT[:LEVEL] or file:PATH:FORMAT[:LEVEL] (repeatable)")
+	fs.DurationVar(&cfg.logDedup, "log-dedup", 0, "collapse consecutive identical error records, logging the repeat count at this interval (0 disables)")
+	fs.StringVar(&cfg.description, "description", "", "human-readable job label attached to every log record and run record")
+	fs.StringVar(&cfg.syntax, "cron-syntax", "optional-seconds", "schedule syntax: optional-seconds, standard, with-seconds or quartz (with-seconds that rejects Quartz-only fields)")
+	fs.DurationVar(&cfg.scheduleOffset, "schedule-offset", 0, "shift every fire time by this duration (may be negative)")
+	fs.BoolVar(&cfg.alignFirstRun, "align-first-run", false, "snap the first run of an @every schedule to the next multiple of its interval")
+	fs.StringVar(&cfg.tz, "tz", "", "time zone schedules and working days are evaluated in (default local)")
+	fs.BoolVar(&cfg.utc, "utc", false, "evaluate schedules and working days in UTC regardless of the host time zone")
+	fs.DurationVar(&cfg.dailyBudget, "daily-runtime-budget", 0, "skip runs once their total execution time today reaches this (0 disables)")
+	fs.StringVar(&cfg.stateFile, "state-file", "", "file keeping the --daily-runtime-budget usage across restarts")
+	fs.Float64Var(&cfg.maxLoad, "skip-if-load-above", 0, "skip runs while the 1-minute load average is above this (Linux only, 0 disables)")
+	fs.BoolVar(&cfg.workingSchedule, "working-schedule", false, "only run on weekdays that are not listed in --holidays")
+	fs.StringVar(&cfg.holidaysFile, "holidays", "", "file of YYYY-MM-DD dates skipped by --working-schedule")
+	fs.StringVar(&cfg.successCodes, "success-codes", "0", "comma-separated exit codes treated as success")
+	fs.BoolVar(&cfg.adaptiveInterval, "adaptive-interval", false, "double an @every interval after each run exiting with --noop-exit-code and reset it after any other run")
+	fs.IntVar(&cfg.noopExitCode, "noop-exit-code", -1, "exit code of a run that found nothing to do; counts as success")
+	fs.DurationVar(&cfg.minInterval, "min-interval", 0, "interval --adaptive-interval resets to (default the @every interval)")
+	fs.DurationVar(&cfg.maxInterval, "max-interval", 0, "longest interval --adaptive-interval stretches to")
+	fs.StringVar(&cfg.expectOutput, "expect-output-regex", "", "fail a run that exits successfully but prints no line matching this regular expression")
+	fs.StringVar(&cfg.failOutput, "fail-output-regex", "", "fail a run that exits successfully but prints a line matching this regular expression")
+	fs.BoolVar(&cfg.stderrIsFailure, "stderr-is-failure", false, "treat a run that exits successfully but writes to stderr as failed")
+	fs.DurationVar(&cfg.jitter, "jitter", 0, "maximum random delay added before each run")
+	fs.Uint64Var(&cfg.jitterSeed, "jitter-seed", 0, "seed for reproducible jitter (0 picks a random seed)")
+	fs.BoolVar(&cfg.restartOnConfigChange, "restart-on-config-change", false, "on SIGHUP, drain running jobs and re-exec cronx with the same arguments")
+	fs.BoolVar(&cfg.abortOnSigint, "abort-on-sigint", false, "kill running jobs on SIGINT instead of waiting for them")
+	fs.Var(&cfg.envVars, "env", "set KEY=VALUE in the command environment (repeatable)")
+	fs.BoolVar(&cfg.expandEnv, "expand-env", false, "expand $VAR and ${VAR} in the command's arguments from its environment before each run")
+	fs.StringVar(&cfg.expandUndefined, "expand-undefined", "empty", "how --expand-env treats undefined variables: empty or error")
+	fs.StringVar(&cfg.envPassthrough, "env-passthrough", "", "comma-separated variables the command inherits from cronx's environment (default all)")
+	fs.StringVar(&cfg.envFile, "env-file", "", "load KEY=VALUE lines into the command environment")
+	fs.Var(&cfg.notifierSpecs, "notifier", "where run notifications go: slack, webhook=URL or none (repeatable; defaults to slack with --slack-webhook)")
+	fs.StringVar(&cfg.notifyEvents, "notify-events", "failure,timeout,recovery", "comma-separated events posted by webhook notifiers: success, failure, timeout, recovery")
+	fs.StringVar(&cfg.publishCommand, "publish-command", "", "command line each run's start, error and complete events are piped to as JSON")
+	fs.DurationVar(&cfg.publishTimeout, "publish-timeout", 10*time.Second, "timeout for each --publish-command invocation")
+	fs.StringVar(&cfg.slackWebhook, "slack-webhook", "", "Slack incoming webhook URL to post run results to")
+	fs.StringVar(&cfg.slackOn, "slack-on", "failure", "which runs to post to Slack: success, failure or both")
+	fs.StringVar(&cfg.slackTemplate, "slack-template", defaultSlackTemplate, "text/template for Slack messages")
+	fs.Var(&cfg.waitForAddrs, "wait-for", "host:port that must accept TCP connections before the scheduler starts (repeatable)")
+	fs.DurationVar(&cfg.waitTimeout, "wait-timeout", 30*time.Second, "how long to wait for --wait-for dependencies")
+	fs.StringVar(&cfg.probeCommand, "probe-command", "", "liveness check command run every --probe-interval")
+	fs.DurationVar(&cfg.probeInterval, "probe-interval", 30*time.Second, "how often --probe-command runs; each attempt is bounded by it")
+	fs.IntVar(&cfg.probeFailures, "probe-failures", 3, "consecutive --probe-command failures that raise an alert")
+	fs.DurationVar(&cfg.reportInterval, "report-interval", 0, "log a status summary at this interval (0 disables)")
+	fs.DurationVar(&cfg.maxLifetime, "max-lifetime", 0, "drain and re-exec cronx once it has run this long (0 disables)")
+	fs.DurationVar(&cfg.exitOnIdle, "exit-on-idle", 0, "shut down cleanly when no run has started for this long (0 disables)")
+	fs.BoolVar(&cfg.sdNotifyFlag, "sd-notify", false, "report readiness, watchdog pings and shutdown to systemd (Type=notify)")
+	fs.IntVar(&cfg.failureThreshold, "failure-threshold", 1, "consecutive failures required before a failure is posted to Slack")
+	fs.BoolVar(&cfg.notifyOnRecovery, "notify-on-recovery", false, "log and post to every notifier when a run succeeds after a failure, regardless of --slack-on and --notify-events")
+	fs.StringVar(&cfg.admissionURL, "admission-webhook", "", "URL asked before each run; any status other than 200 skips the run")
+	fs.DurationVar(&cfg.admissionTimeout, "admission-timeout", 5*time.Second, "timeout for each --admission-webhook request")
+	fs.BoolVar(&cfg.admissionFailOpen, "admission-fail-open", false, "run anyway when the admission webhook cannot be reached")
+	fs.StringVar(&cfg.stdinURL, "stdin-url", "", "URL fetched before each run whose body is piped to the command's stdin")
+	fs.DurationVar(&cfg.stdinTimeout, "stdin-timeout", 30*time.Second, "timeout for each --stdin-url request")
+	fs.StringVar(&cfg.stdinOnError, "stdin-on-error", "fail", "what a failed --stdin-url fetch does to the run: fail or skip")
+	fs.IntVar(&cfg.singletonPort, "singleton-port", 0, "refuse to start if this localhost TCP port is already bound by another instance")
+	fs.StringVar(&cfg.httpAddr, "http-addr", "", "address to serve the /runs status endpoint on (e.g. :8080)")
+	fs.IntVar(&cfg.historySize, "history-size", 20, "number of recent runs kept for /runs")
+	fs.StringVar(&cfg.historyExport, "history-export", "", "write every run since startup to this .csv or .json file on shutdown")
+	fs.StringVar(&cfg.commandAllowlist, "command-allowlist", "", "file of command names or paths that may be run; anything else is rejected at startup")
+	fs.StringVar(&cfg.argsFile, "args-file", "", "file whose non-empty lines are appended to the command's arguments")
+	fs.StringVar(&cfg.wrapper, "wrapper", "", "argv template wrapping the command, e.g. \"nice -n 10 {cmd} {args}\"")
+	fs.StringVar(&cfg.cwdPerRun, "command-cwd-per-run", "", "run each command in a fresh directory created under this one")
+	fs.BoolVar(&cfg.cleanupCwd, "cleanup-cwd", false, "remove the --command-cwd-per-run directory after each run")
+	fs.BoolVar(&cfg.logOutputOnFailure, "log-output-on-failure", false, "hold back command output and log its last 4 KiB only when a run fails")
+	fs.StringVar(&cfg.logDir, "log-dir", "", "append command output to <dir>/<job>.log, reopened on SIGHUP")
+	fs.BoolVar(&cfg.logOutputLines, "log-output", false, "log each line of command output as a record instead of passing it through")
+	fs.StringVar(&cfg.stdoutLevel, "stdout-level", "info", "level of --log-output records for stdout lines")
+	fs.StringVar(&cfg.stderrLevel, "stderr-level", "warn", "level of --log-output records for stderr lines")
+	fs.BoolVar(&cfg.parseJSONOutput, "parse-json-output", false, "with --log-output or --log-output-on-failure, attach output lines holding a JSON object as structured data")
+	fs.StringVar(&cfg.cpuAffinity, "cpu-affinity", "", "comma-separated CPUs to pin each command to, e.g. 0,1 (Linux only)")
+	fs.BoolVar(&cfg.pty, "pty", false, "run each command on a pseudo-terminal so it behaves as if interactive (Linux only)")
+	fs.StringVar(&cfg.chroot, "chroot", "", "run each command with this root directory (Unix only, requires root)")
+	fs.BoolVar(&cfg.scratch, "scratch", false, "give each run a temporary directory exported as CRONX_SCRATCH")
+	fs.IntVar(&cfg.timeoutPercent, "timeout-percent", 0, "kill runs exceeding this percentage of the schedule interval (0 disables)")
+	fs.DurationVar(&cfg.outputStallTimeout, "output-stall-timeout", 0, "kill runs that write nothing to stdout or stderr for this long (0 disables)")
+	fs.BoolVar(&cfg.timeoutAsSuccess, "timeout-as-success", false, "treat a run killed by its timeout as successful")
+	fs.DurationVar(&cfg.timeoutGrace, "timeout-grace", 0, "send --timeout-warn-signal this long before the timeout kill")
+	fs.StringVar(&cfg.timeoutWarnSignal, "timeout-warn-signal", "SIGTERM", "signal sent when the timeout grace period starts")
+	return cfg
+}
+
+// unknownFlagError reports a flag that fs does not define, with the
+// closest defined flag when one is near enough.
+type unknownFlagError struct {
+	name       string
+	suggestion string
+}
+
+func (e *unknownFlagError) Error() string {
+	return "flag provided but not defined: --" + e.name
+}
+
+// parseFlags parses args into fs. Unlike fs.Parse, it prints nothing: an
+// unknown flag is returned as an *unknownFlagError carrying the closest
+// known flag as a suggestion instead of the full usage text, so typos are
+// easy to spot, and -h is returned as flag.ErrHelp.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	out := fs.Output()
+	fs.Init(fs.Name(), flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	err := fs.Parse(args)
+	fs.SetOutput(out)
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	if name, ok := strings.CutPrefix(err.Error(), "flag provided but not defined: "); ok {
+		name = strings.TrimLeft(name, "-")
+		return &unknownFlagError{name: name, suggestion: suggestFlag(fs, name)}
+	}
+	return err
+}
+
+// suggestFlag returns the defined flag closest to name, or "" if none is
+// within maxSuggestDistance edits.
+func suggestFlag(fs *flag.FlagSet, name string) string {
+	best, bestDistance := "", maxSuggestDistance+1
+	fs.VisitAll(func(f *flag.Flag) {
+		if d := editDistance(name, f.Name); d < bestDistance {
+			best, bestDistance = f.Name, d
+		}
+	})
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseFlagsLeavesCommandFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantJitter time.Duration
+		wantArgs   []string
+	}{
+		{
+			name:     "no cronx flags",
+			args:     []string{"@daily", "ls", "-la", "--color=auto"},
+			wantArgs: []string{"@daily", "ls", "-la", "--color=auto"},
+		},
+		{
+			name:       "cronx flags before the schedule",
+			args:       []string{"--jitter", "1s", "@daily", "ls", "-la"},
+			wantJitter: time.Second,
+			wantArgs:   []string{"@daily", "ls", "-la"},
+		},
+		{
+			name:     "command flag named like a cronx flag",
+			args:     []string{"@daily", "sleepy", "--jitter", "5s"},
+			wantArgs: []string{"@daily", "sleepy", "--jitter", "5s"},
+		},
+		{
+			name:       "double dash ends cronx flags",
+			args:       []string{"--jitter=2s", "--", "@daily", "grep", "--", "-v"},
+			wantJitter: 2 * time.Second,
+			wantArgs:   []string{"@daily", "grep", "--", "-v"},
+		},
+		{
+			name:     "single dash argument",
+			args:     []string{"@hourly", "tar", "-czf", "-", "/data"},
+			wantArgs: []string{"@hourly", "tar", "-czf", "-", "/data"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("cronx", flag.ContinueOnError)
+			cfg := defineFlags(fs)
+			if err := parseFlags(fs, tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if cfg.jitter != tt.wantJitter {
+				t.Errorf("jitter = %s, want %s", cfg.jitter, tt.wantJitter)
+			}
+			if !slices.Equal(fs.Args(), tt.wantArgs) {
+				t.Errorf("positionals = %q, want %q", fs.Args(), tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestParseFlagsErrors(t *testing.T) {
+	fs := flag.NewFlagSet("cronx", flag.ContinueOnError)
+	defineFlags(fs)
+
+	var unknown *unknownFlagError
+	if err := parseFlags(fs, []string{"--jiter", "1s", "@daily", "ls"}); !errors.As(err, &unknown) {
+		t.Fatalf("parseFlags = %v, want an unknown flag", err)
+	}
+	if unknown.name != "jiter" || unknown.suggestion != "jitter" {
+		t.Errorf("unknown flag %q with suggestion %q, want jiter and jitter", unknown.name, unknown.suggestion)
+	}
+
+	if err := parseFlags(fs, []string{"--zzzzzzzz"}); !errors.As(err, &unknown) || unknown.suggestion != "" {
+		t.Errorf("parseFlags = %v, want an unknown flag without a suggestion", err)
+	}
+	if err := parseFlags(fs, []string{"-h"}); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("parseFlags(-h) = %v, want flag.ErrHelp", err)
+	}
+	if err := parseFlags(fs, []string{"--jitter", "soon"}); err == nil || errors.As(err, &unknown) {
+		t.Errorf("parseFlags = %v, want an invalid value error", err)
+	}
+}
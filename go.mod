@@ -2,4 +2,7 @@ module github.com/focela/cronx
 
 go 1.25.1
 
-require github.com/robfig/cron/v3 v3.0.1
+require (
+	github.com/robfig/cron/v3 v3.0.1
+	golang.org/x/sys v0.40.0
+)
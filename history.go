@@ -0,0 +1,103 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/focela/cronx/pkg/runner"
+)
+
+// runRecord is the outcome of a finished run as reported over HTTP.
+type runRecord struct {
+	RunID       string    `json:"run_id"`
+	Description string    `json:"description,omitempty"`
+	Scheduled   time.Time `json:"scheduled"`
+	Started     time.Time `json:"started"`
+	Duration    string    `json:"duration"`
+	ExitCode    int       `json:"exit_code"`
+	Success     bool      `json:"success"`
+	Error       string    `json:"error,omitempty"`
+	OutputTail  string    `json:"output_tail"`
+	CPUUserMS   int64     `json:"cpu_user_ms"`
+	CPUSysMS    int64     `json:"cpu_sys_ms"`
+	MaxRSSKB    int64     `json:"max_rss_kb,omitempty"`
+}
+
+// newRunRecord describes a finished run.
+func newRunRecord(run *runner.Run) runRecord {
+	r := runRecord{
+		RunID:       run.ID,
+		Description: run.Description,
+		Scheduled:   run.Scheduled,
+		Started:     run.Started,
+		Duration:    run.Duration.String(),
+		ExitCode:    run.ExitCode,
+		Success:     run.Err == nil,
+		OutputTail:  run.Output,
+		CPUUserMS:   run.UserTime.Milliseconds(),
+		CPUSysMS:    run.SystemTime.Milliseconds(),
+		MaxRSSKB:    run.MaxRSS,
+	}
+	if run.Err != nil {
+		r.Error = run.Err.Error()
+	}
+	return r
+}
+
+// history is a concurrency-safe ring buffer of the most recent runs.
+type history struct {
+	mu      sync.Mutex
+	records []runRecord
+	next    int
+	full    bool
+	// total counts every run added, including evicted ones.
+	total int
+}
+
+// newHistory returns a history holding up to size runs.
+func newHistory(size int) *history {
+	return &history{records: make([]runRecord, size)}
+}
+
+// add stores r, evicting the oldest run when the buffer is full.
+func (h *history) add(r runRecord) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.records[h.next] = r
+	h.total++
+	h.next = (h.next + 1) % len(h.records)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// list returns the stored runs, oldest first.
+func (h *history) list() []runRecord {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.full {
+		return append([]runRecord(nil), h.records[:h.next]...)
+	}
+	return append(append([]runRecord(nil), h.records[h.next:]...), h.records[:h.next]...)
+}
+
+// last returns the most recent run and the number of runs added so far.
+// ok is false when no run has been added.
+func (h *history) last() (r runRecord, total int, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.total == 0 {
+		return runRecord{}, 0, false
+	}
+	return h.records[(h.next+len(h.records)-1)%len(h.records)], h.total, true
+}
@@ -0,0 +1,71 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// jobLog is the file the command's output goes to with --log-dir. It can
+// be reopened so that external tools such as logrotate can move it away.
+type jobLog struct {
+	path string
+
+	mu sync.Mutex
+	f  *os.File
+}
+
+// openJobLog creates dir if needed and opens <dir>/<job>.log for appending,
+// which also proves that the directory is writable.
+func openJobLog(dir, job string) (*jobLog, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	l := &jobLog{path: filepath.Join(dir, job+".log")}
+	f, err := l.open()
+	if err != nil {
+		return nil, err
+	}
+	l.f = f
+	return l, nil
+}
+
+// open opens the log file for appending.
+func (l *jobLog) open() (*os.File, error) {
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open job log: %w", err)
+	}
+	return f, nil
+}
+
+// Write implements io.Writer.
+func (l *jobLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Write(p)
+}
+
+// reopen replaces the open file with a fresh one at the same path. On
+// failure the old file is kept so that no output is lost.
+func (l *jobLog) reopen() {
+	f, err := l.open()
+	if err != nil {
+		logger.Error("failed to reopen job log", "path", l.path, "error", err)
+		return
+	}
+	l.mu.Lock()
+	old := l.f
+	l.f = f
+	l.mu.Unlock()
+	old.Close()
+	logger.Info("reopened job log", "path", l.path)
+}
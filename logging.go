@@ -0,0 +1,512 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/focela/cronx/pkg/runner"
+)
+
+// logBufferSize is the size of the batch buffer used by --log-flush-interval.
+const logBufferSize = 64 * 1024
+
+// lifecycleKey marks records that --quiet must not suppress.
+type lifecycleKey struct{}
+
+// lifecycle is the context for startup banner and shutdown summary records.
+var lifecycle = context.WithValue(context.Background(), lifecycleKey{}, true)
+
+// quietHandler drops records below warning level unless they are
+// logged with the lifecycle context.
+type quietHandler struct {
+	slog.Handler
+}
+
+// Enabled reports whether the record passes the quiet filter and the
+// wrapped handler's own level.
+func (h quietHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if level < slog.LevelWarn && ctx.Value(lifecycleKey{}) == nil {
+		return false
+	}
+	return h.Handler.Enabled(ctx, level)
+}
+
+// WithAttrs keeps the quiet filter on derived handlers.
+func (h quietHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return quietHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup keeps the quiet filter on derived handlers.
+func (h quietHandler) WithGroup(name string) slog.Handler {
+	return quietHandler{h.Handler.WithGroup(name)}
+}
+
+// fallbackWriter writes to w until it reports a broken pipe, then
+// switches permanently to fallback so logging survives a closed reader.
+type fallbackWriter struct {
+	mu       sync.Mutex
+	w        io.Writer
+	fallback io.Writer
+	broken   bool
+	// onBroken is called once, in its own goroutine, after the switch.
+	onBroken func()
+}
+
+// Write implements io.Writer.
+func (f *fallbackWriter) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.broken {
+		n, err := f.w.Write(p)
+		if !errors.Is(err, syscall.EPIPE) {
+			return n, err
+		}
+		f.broken = true
+		if f.onBroken != nil {
+			go f.onBroken()
+		}
+	}
+	return f.fallback.Write(p)
+}
+
+// bufferedWriter batches writes in memory until flushed or full.
+type bufferedWriter struct {
+	mu sync.Mutex
+	w  *bufio.Writer
+}
+
+// newBufferedWriter returns a writer batching writes to w.
+func newBufferedWriter(w io.Writer) *bufferedWriter {
+	return &bufferedWriter{w: bufio.NewWriterSize(w, logBufferSize)}
+}
+
+// Write implements io.Writer.
+func (b *bufferedWriter) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.w.Write(p)
+}
+
+// Flush writes any buffered data to the underlying writer.
+func (b *bufferedWriter) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.w.Flush()
+}
+
+// flushEvery flushes the buffer every interval until the process exits.
+func (b *bufferedWriter) flushEvery(interval time.Duration) {
+	for range time.Tick(interval) {
+		b.Flush()
+	}
+}
+
+// flushHandler flushes a bufferedWriter after each error record so that
+// errors are never held back by batching.
+type flushHandler struct {
+	slog.Handler
+	buf *bufferedWriter
+}
+
+// Handle writes the record and flushes it if it is an error.
+func (h flushHandler) Handle(ctx context.Context, r slog.Record) error {
+	if err := h.Handler.Handle(ctx, r); err != nil {
+		return err
+	}
+	if r.Level >= slog.LevelError {
+		return h.buf.Flush()
+	}
+	return nil
+}
+
+// WithAttrs keeps flushing on derived handlers.
+func (h flushHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return flushHandler{h.Handler.WithAttrs(attrs), h.buf}
+}
+
+// WithGroup keeps flushing on derived handlers.
+func (h flushHandler) WithGroup(name string) slog.Handler {
+	return flushHandler{h.Handler.WithGroup(name), h.buf}
+}
+
+// logSink is one log destination with its own format and level.
+type logSink struct {
+	// target is "stdout", "stderr" or a file path.
+	target string
+	// format is "json", "text" or "logfmt".
+	format string
+	level  slog.Leveler
+}
+
+// validLogFormat reports whether format names a supported log format.
+func validLogFormat(format string) bool {
+	return format == "json" || format == "text" || format == "logfmt"
+}
+
+// logfmtAttr renames and rewrites the built-in attributes in the style
+// most logfmt consumers expect: ts=... level=info msg=...
+func logfmtAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return a
+	}
+	switch a.Key {
+	case slog.TimeKey:
+		a.Key = "ts"
+	case slog.LevelKey:
+		a.Value = slog.StringValue(strings.ToLower(a.Value.String()))
+	}
+	return a
+}
+
+// parseLogSink parses a --log-sink value of the form
+// "stdout:FORMAT[:LEVEL]", "stderr:FORMAT[:LEVEL]" or
+// "file:PATH:FORMAT[:LEVEL]", where PATH may contain colons. The level
+// defaults to defaultLevel.
+func parseLogSink(spec string, defaultLevel slog.Leveler) (logSink, error) {
+	target, rest, _ := strings.Cut(spec, ":")
+	sink := logSink{target: target, level: defaultLevel}
+
+	switch target {
+	case "file":
+		// FORMAT[:LEVEL] is taken from the end so that the path may
+		// contain colons, as a Windows drive letter does.
+		i := strings.LastIndexByte(rest, ':')
+		if i >= 0 && !validLogFormat(rest[i+1:]) {
+			i = strings.LastIndexByte(rest[:i], ':')
+		}
+		if i == 0 || rest == "" {
+			return logSink{}, fmt.Errorf("invalid log sink '%s': missing file path", spec)
+		}
+		if i < 0 {
+			return logSink{}, fmt.Errorf("invalid log sink '%s': expected FORMAT[:LEVEL] after the target", spec)
+		}
+		sink.target, rest = rest[:i], rest[i+1:]
+	case "stdout", "stderr":
+	default:
+		return logSink{}, fmt.Errorf("invalid log sink '%s': target must be stdout, stderr or file", spec)
+	}
+
+	parts := strings.Split(rest, ":")
+	if rest == "" || len(parts) > 2 {
+		return logSink{}, fmt.Errorf("invalid log sink '%s': expected FORMAT[:LEVEL] after the target", spec)
+	}
+	sink.format = parts[0]
+	if !validLogFormat(sink.format) {
+		return logSink{}, fmt.Errorf("invalid log sink '%s': format must be json, text or logfmt", spec)
+	}
+	if len(parts) == 2 {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(parts[1])); err != nil {
+			return logSink{}, fmt.Errorf("invalid log sink '%s': %w", spec, err)
+		}
+		sink.level = level
+	}
+	return sink, nil
+}
+
+// newSinkHandler opens the sink's destination and returns a handler for it.
+// When flushInterval is positive, writes are batched in buf.
+func newSinkHandler(sink logSink, flushInterval time.Duration) (slog.Handler, *bufferedWriter, error) {
+	var w io.Writer
+	switch sink.target {
+	case "stdout":
+		w = logOutput
+	case "stderr":
+		w = os.Stderr
+	default:
+		f, err := os.OpenFile(sink.target, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		w = f
+	}
+
+	var buf *bufferedWriter
+	if flushInterval > 0 {
+		buf = newBufferedWriter(w)
+		w = buf
+	}
+
+	opts := &slog.HandlerOptions{Level: sink.level}
+	var h slog.Handler
+	switch sink.format {
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	case "logfmt":
+		// The text handler already quotes and escapes values as logfmt
+		// requires; only the built-in keys differ.
+		opts.ReplaceAttr = logfmtAttr
+		h = slog.NewTextHandler(w, opts)
+	default:
+		h = slog.NewJSONHandler(w, opts)
+	}
+	if buf != nil {
+		h = flushHandler{h, buf}
+	}
+	return h, buf, nil
+}
+
+// setupLogging replaces logger with one writing to all sinks and sets
+// flushLogs to flush every batched sink.
+func setupLogging(sinks []logSink, flushInterval time.Duration) error {
+	var handlers fanoutHandler
+	var bufs []*bufferedWriter
+	for _, sink := range sinks {
+		h, buf, err := newSinkHandler(sink, flushInterval)
+		if err != nil {
+			return err
+		}
+		handlers = append(handlers, h)
+		if buf != nil {
+			bufs = append(bufs, buf)
+		}
+	}
+
+	if len(handlers) == 1 {
+		logger = slog.New(handlers[0])
+	} else {
+		logger = slog.New(handlers)
+	}
+	flushLogs = func() {
+		for _, buf := range bufs {
+			buf.Flush()
+		}
+	}
+	for _, buf := range bufs {
+		go buf.flushEvery(flushInterval)
+	}
+	return nil
+}
+
+// fanoutHandler sends each record to every handler that accepts its level.
+type fanoutHandler []slog.Handler
+
+// Enabled reports whether any handler accepts level.
+func (f fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle passes r to each handler that accepts its level.
+func (f fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range f {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithAttrs applies attrs to every handler.
+func (f fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(fanoutHandler, len(f))
+	for i, h := range f {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return out
+}
+
+// WithGroup applies the group to every handler.
+func (f fanoutHandler) WithGroup(name string) slog.Handler {
+	out := make(fanoutHandler, len(f))
+	for i, h := range f {
+		out[i] = h.WithGroup(name)
+	}
+	return out
+}
+
+// logCommandOutput logs a failed run's captured output as one record. With
+// parseJSON, each line is logged on its own instead, and lines holding a
+// JSON object are attached as structured data rather than a string.
+func logCommandOutput(run *runner.Run, parseJSON bool) {
+	if !parseJSON {
+		logger.Warn("command output", "run_id", run.ID, "output", run.Output)
+		return
+	}
+	for _, line := range strings.Split(strings.TrimRight(run.Output, "\n"), "\n") {
+		logger.Warn("command output", "run_id", run.ID, outputAttr(strings.TrimSuffix(line, "\r"), true))
+	}
+}
+
+// outputAttr returns a line of command output as the "output" attribute.
+// With parseJSON, a line holding a JSON object becomes a group of its
+// fields in sorted order.
+func outputAttr(line string, parseJSON bool) slog.Attr {
+	if parseJSON {
+		var fields map[string]any
+		if err := json.Unmarshal([]byte(line), &fields); err == nil && fields != nil {
+			var attrs []any
+			for _, k := range slices.Sorted(maps.Keys(fields)) {
+				attrs = append(attrs, k, fields[k])
+			}
+			return slog.Group("output", attrs...)
+		}
+	}
+	return slog.String("output", line)
+}
+
+// dedupHandler collapses consecutive identical error records. A repeat is
+// dropped and counted; the count is logged when a different error
+// arrives, every report interval and on flush.
+type dedupHandler struct {
+	slog.Handler
+	state *dedupState
+}
+
+// dedupState is shared by a dedupHandler and the handlers derived from it.
+type dedupState struct {
+	mu sync.Mutex
+	// key identifies the last error record; repeated counts its drops.
+	key      string
+	msg      string
+	level    slog.Level
+	handler  slog.Handler
+	repeated int
+}
+
+// newDedupHandler wraps h and logs pending repeat counts every interval.
+func newDedupHandler(h slog.Handler, interval time.Duration) dedupHandler {
+	d := dedupHandler{h, &dedupState{}}
+	go func() {
+		for range time.Tick(interval) {
+			d.state.flush()
+		}
+	}()
+	return d
+}
+
+// Handle drops r if it repeats the previous error record.
+func (h dedupHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < slog.LevelError {
+		return h.Handler.Handle(ctx, r)
+	}
+
+	var key strings.Builder
+	key.WriteString(r.Level.String() + " " + r.Message)
+	r.Attrs(func(a slog.Attr) bool {
+		key.WriteString(" " + a.String())
+		return true
+	})
+
+	s := h.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key.String() == s.key {
+		s.repeated++
+		return nil
+	}
+	s.flushLocked()
+	s.key, s.msg, s.level, s.handler = key.String(), r.Message, r.Level, h.Handler
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs keeps deduplicating on derived handlers.
+func (h dedupHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return dedupHandler{h.Handler.WithAttrs(attrs), h.state}
+}
+
+// WithGroup keeps deduplicating on derived handlers.
+func (h dedupHandler) WithGroup(name string) slog.Handler {
+	return dedupHandler{h.Handler.WithGroup(name), h.state}
+}
+
+// flush logs the pending repeat count, if any.
+func (s *dedupState) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushLocked()
+}
+
+// flushLocked logs the pending repeat count with s.mu held. The error
+// stays current, so further repeats keep being collapsed.
+func (s *dedupState) flushLocked() {
+	if s.repeated == 0 {
+		return
+	}
+	r := slog.NewRecord(time.Now(), s.level, "last error repeated", 0)
+	r.AddAttrs(slog.String("message", s.msg), slog.Int("repeated", s.repeated))
+	s.handler.Handle(context.Background(), r)
+	s.repeated = 0
+}
+
+// maxOutputLine is the longest partial output line held back by a
+// lineWriter before it is logged anyway.
+const maxOutputLine = 64 * 1024
+
+// lineWriter logs each line of one run's command output as its own
+// record at a fixed level.
+type lineWriter struct {
+	stream string
+	level  slog.Level
+	runID  string
+	// parseJSON logs JSON object lines as structured fields.
+	parseJSON bool
+
+	mu      sync.Mutex
+	partial []byte
+}
+
+// Write implements io.Writer.
+func (l *lineWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.partial = append(l.partial, p...)
+	for {
+		i := bytes.IndexByte(l.partial, '\n')
+		if i < 0 {
+			break
+		}
+		l.log(l.partial[:i])
+		l.partial = l.partial[i+1:]
+	}
+	if len(l.partial) >= maxOutputLine {
+		l.log(l.partial)
+		l.partial = nil
+	}
+	return len(p), nil
+}
+
+// Close logs a trailing line that did not end in a newline.
+func (l *lineWriter) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.partial) > 0 {
+		l.log(l.partial)
+		l.partial = nil
+	}
+	return nil
+}
+
+// log emits one line with l.mu held.
+func (l *lineWriter) log(line []byte) {
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	logger.Log(context.Background(), l.level, "command output", "stream", l.stream, "run_id", l.runID, outputAttr(string(line), l.parseJSON))
+}
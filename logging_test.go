@@ -0,0 +1,54 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestParseLogSink(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    logSink
+		wantErr bool
+	}{
+		{spec: "stdout:json", want: logSink{target: "stdout", format: "json", level: slog.LevelInfo}},
+		{spec: "stderr:text:debug", want: logSink{target: "stderr", format: "text", level: slog.LevelDebug}},
+		{spec: "file:/var/log/cronx.log:logfmt", want: logSink{target: "/var/log/cronx.log", format: "logfmt", level: slog.LevelInfo}},
+		{spec: "file:/var/log/cronx.log:json:warn", want: logSink{target: "/var/log/cronx.log", format: "json", level: slog.LevelWarn}},
+		{spec: `file:C:\ProgramData\cronx\cronx.log:json`, want: logSink{target: `C:\ProgramData\cronx\cronx.log`, format: "json", level: slog.LevelInfo}},
+		{spec: `file:C:\ProgramData\cronx\cronx.log:text:error`, want: logSink{target: `C:\ProgramData\cronx\cronx.log`, format: "text", level: slog.LevelError}},
+		{spec: "stdout", wantErr: true},
+		{spec: "stdout:xml", wantErr: true},
+		{spec: "stdout:json:loud", wantErr: true},
+		{spec: "stdout:json:info:extra", wantErr: true},
+		{spec: "syslog:json", wantErr: true},
+		{spec: "file:", wantErr: true},
+		{spec: "file::json", wantErr: true},
+		{spec: "file:/var/log/cronx.log", wantErr: true},
+		{spec: `file:C:\cronx.log`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := parseLogSink(tt.spec, slog.LevelInfo)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseLogSink(%q) = %+v, want an error", tt.spec, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseLogSink(%q): %v", tt.spec, err)
+			}
+			if got.target != tt.want.target || got.format != tt.want.format || got.level.Level() != tt.want.level.Level() {
+				t.Errorf("parseLogSink(%q) = %+v, want %+v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
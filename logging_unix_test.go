@@ -0,0 +1,91 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+//go:build !windows
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestFallbackWriterSwitchesOnBrokenPipe(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	r.Close()
+
+	var fallback bytes.Buffer
+	broken := make(chan struct{}, 2)
+	f := &fallbackWriter{w: w, fallback: &fallback, onBroken: func() { broken <- struct{}{} }}
+	for _, line := range []string{"first\n", "second\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("Write(%q): %v", line, err)
+		}
+	}
+	if got := fallback.String(); got != "first\nsecond\n" {
+		t.Errorf("fallback got %q, want both lines", got)
+	}
+	select {
+	case <-broken:
+	case <-time.After(5 * time.Second):
+		t.Fatal("onBroken was not called")
+	}
+	select {
+	case <-broken:
+		t.Error("onBroken was called twice")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestStdoutReaderClosingEarly(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stderr bytes.Buffer
+	cmd := cronxCommand("@every 1s", "true")
+	cmd.Stdout, cmd.Stderr = w, &stderr
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	t.Cleanup(func() {
+		if cmd.ProcessState == nil {
+			cmd.Process.Kill()
+			cmd.Wait()
+		}
+	})
+
+	// Like head -1: read one record and go away.
+	if _, err := bufio.NewReader(r).ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+
+	// The first run logs into the closed pipe.
+	time.Sleep(1500 * time.Millisecond)
+	if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		t.Fatalf("cronx died writing to the closed pipe: %v", err)
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("cronx exited with %v; stderr:\n%s", err, stderr.String())
+	}
+	for _, msg := range []string{"stdout is a broken pipe, logging to stderr", "command finished", "scheduler stopped successfully"} {
+		if !strings.Contains(stderr.String(), msg) {
+			t.Errorf("stderr is missing %q:\n%s", msg, stderr.String())
+		}
+	}
+}
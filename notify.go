@@ -0,0 +1,294 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/focela/cronx/pkg/runner"
+)
+
+const (
+	// notifyTimeout bounds each notification request.
+	notifyTimeout = 10 * time.Second
+
+	// defaultSlackTemplate is used when --slack-template is not given.
+	defaultSlackTemplate = `{{if .Success}}:white_check_mark:{{else}}:x:{{end}} cronx job *{{.Job}}* ` +
+		`{{if .Recovered}}recovered{{else if .Success}}succeeded{{else}}failed{{end}} (exit code {{.ExitCode}}, {{.Duration}})` +
+		`{{if .Error}}: {{.Error}}{{end}}`
+)
+
+// Notification events, as named by --notify-events.
+const (
+	eventSuccess  = "success"
+	eventFailure  = "failure"
+	eventTimeout  = "timeout"
+	eventRecovery = "recovery"
+)
+
+// notification is the data available to notification templates and the
+// body posted by webhook notifiers.
+type notification struct {
+	Event       string `json:"event"`
+	Job         string `json:"job"`
+	Description string `json:"description,omitempty"`
+	Command     string `json:"command"`
+	Args        string `json:"args"`
+	RunID       string `json:"run_id"`
+	ExitCode    int    `json:"exit_code"`
+	Duration    string `json:"duration"`
+	Success     bool   `json:"success"`
+	Recovered   bool   `json:"recovered"`
+	Failures    int    `json:"failures"`
+	Error       string `json:"error,omitempty"`
+	// Output is the tail of the run's output.
+	Output string `json:"output,omitempty"`
+}
+
+// notifier delivers notifications about finished runs. Implementations
+// decide which runs they want, log their own failures and never block
+// the scheduler for longer than notifyTimeout.
+type notifier interface {
+	notify(n notification)
+}
+
+// notifiers fans a notification out to each of its members.
+type notifiers []notifier
+
+// notify implements notifier.
+func (ns notifiers) notify(n notification) {
+	for _, nt := range ns {
+		nt.notify(n)
+	}
+}
+
+// nopNotifier discards notifications; it is selected by --notifier none.
+type nopNotifier struct{}
+
+// notify implements notifier.
+func (nopNotifier) notify(notification) {}
+
+// newNotification describes a finished run.
+func newNotification(command string, args []string, run *runner.Run) notification {
+	n := notification{
+		Event:       eventSuccess,
+		Job:         run.Job,
+		Description: run.Description,
+		Command:     command,
+		Args:        strings.Join(args, " "),
+		RunID:       run.ID,
+		ExitCode:    run.ExitCode,
+		Duration:    run.Duration.String(),
+		Success:     run.Err == nil,
+		Output:      run.Output,
+	}
+	if run.Err != nil {
+		n.Event = eventFailure
+		if errors.Is(run.Err, runner.ErrTimedOut) {
+			n.Event = eventTimeout
+		}
+		n.Error = run.Err.Error()
+	}
+	return n
+}
+
+// recoveryTracker counts consecutive failures and detects the first
+// success after threshold of them.
+type recoveryTracker struct {
+	mu        sync.Mutex
+	threshold int
+	failures  int
+}
+
+// record notes the outcome of a finished run and returns the number of
+// consecutive failures so far, and whether the run ended a streak of at
+// least threshold failures. Overlapping runs are ordered by completion.
+func (t *recoveryTracker) record(success bool) (recovered bool, failures int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if success {
+		recovered = t.failures >= t.threshold
+		t.failures = 0
+		return recovered, 0
+	}
+	t.failures++
+	return false, t.failures
+}
+
+// notifyRun records the outcome of n with recovery and hands it to nt,
+// unless it is a failure below the threshold, which is not actionable yet.
+func notifyRun(nt notifier, recovery *recoveryTracker, n notification, onRecovery bool) {
+	n.Recovered, n.Failures = recovery.record(n.Success)
+	if n.Recovered {
+		n.Event = eventRecovery
+	}
+	if recovery.threshold > 1 && n.Failures == recovery.threshold {
+		logger.Warn("failure threshold reached", "command", n.Command, "run_id", n.RunID, "failures", n.Failures)
+	}
+	if n.Recovered && onRecovery {
+		logger.Info("job recovered", "command", n.Command, "run_id", n.RunID)
+	}
+	if n.Success || n.Failures >= recovery.threshold {
+		nt.notify(n)
+	}
+}
+
+// slackNotifier posts run results to a Slack incoming webhook.
+type slackNotifier struct {
+	url string
+	on  string
+	// onRecovery also posts recoveries when on excludes successes.
+	onRecovery bool
+	tmpl       *template.Template
+	client     *http.Client
+}
+
+// newSlackNotifier validates the --slack-* settings.
+func newSlackNotifier(url, on, tmpl string, onRecovery bool) (*slackNotifier, error) {
+	if on != "success" && on != "failure" && on != "both" {
+		return nil, fmt.Errorf("invalid --slack-on '%s': expected success, failure or both", on)
+	}
+	t, err := template.New("slack").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --slack-template: %w", err)
+	}
+	return &slackNotifier{
+		url:        url,
+		on:         on,
+		onRecovery: onRecovery,
+		tmpl:       t,
+		client:     &http.Client{Timeout: notifyTimeout},
+	}, nil
+}
+
+// wants reports whether n should be posted.
+func (s *slackNotifier) wants(n notification) bool {
+	if n.Recovered && s.onRecovery {
+		return true
+	}
+	return s.on == "both" || (n.Success && s.on == "success") || (!n.Success && s.on == "failure")
+}
+
+// notify posts n to Slack. Failures are logged and never fatal.
+func (s *slackNotifier) notify(n notification) {
+	if !s.wants(n) {
+		return
+	}
+
+	var text bytes.Buffer
+	if err := s.tmpl.Execute(&text, n); err != nil {
+		logger.Error("failed to render slack message", "error", err)
+		return
+	}
+	body, err := json.Marshal(map[string]string{"text": text.String()})
+	if err != nil {
+		logger.Error("failed to encode slack message", "error", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		logger.Error("failed to create slack request", "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		logger.Error("failed to post slack message", "error", redactURLError(err))
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		logger.Error("slack webhook rejected message", "status", resp.StatusCode)
+	}
+}
+
+// webhookNotifier posts each wanted notification as JSON to a URL.
+type webhookNotifier struct {
+	url    string
+	events map[string]bool
+	client *http.Client
+}
+
+// newWebhookNotifier returns a notifier posting the given comma-separated
+// events to url. onRecovery adds recoveries to events.
+func newWebhookNotifier(url, events string, onRecovery bool) (*webhookNotifier, error) {
+	w := &webhookNotifier{url: url, events: make(map[string]bool), client: &http.Client{Timeout: notifyTimeout}}
+	for _, e := range strings.Split(events, ",") {
+		switch e = strings.TrimSpace(e); e {
+		case eventSuccess, eventFailure, eventTimeout, eventRecovery:
+			w.events[e] = true
+		case "":
+		default:
+			return nil, fmt.Errorf("invalid --notify-events '%s': expected success, failure, timeout or recovery", e)
+		}
+	}
+	if onRecovery {
+		w.events[eventRecovery] = true
+	}
+	return w, nil
+}
+
+// wants reports whether n should be posted. A recovery is also a success
+// and a timeout is also a failure.
+func (w *webhookNotifier) wants(n notification) bool {
+	switch {
+	case n.Recovered && w.events[eventRecovery]:
+		return true
+	case n.Event == eventTimeout && w.events[eventTimeout]:
+		return true
+	case n.Success:
+		return w.events[eventSuccess]
+	default:
+		return w.events[eventFailure]
+	}
+}
+
+// notify posts n as JSON. Failures are logged and never fatal.
+func (w *webhookNotifier) notify(n notification) {
+	if !w.wants(n) {
+		return
+	}
+	body, err := json.Marshal(n)
+	if err != nil {
+		logger.Error("failed to encode webhook notification", "error", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		logger.Error("failed to create webhook request", "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		logger.Error("failed to post webhook notification", "url", redactURL(w.url), "error", redactURLError(err))
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		logger.Error("webhook rejected notification", "url", redactURL(w.url), "status", resp.StatusCode)
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/focela/cronx/pkg/runner"
+)
+
+// fakeNotifier records the notifications it receives.
+type fakeNotifier struct {
+	mu   sync.Mutex
+	sent []notification
+}
+
+func (f *fakeNotifier) notify(n notification) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, n)
+}
+
+func (f *fakeNotifier) events() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var events []string
+	for _, n := range f.sent {
+		events = append(events, n.Event)
+	}
+	return events
+}
+
+func testRun(id string, err error) *runner.Run {
+	run := &runner.Run{ID: id, Job: "backup", Output: "copied 3 files\n", Err: err}
+	if err != nil {
+		run.ExitCode = 1
+	}
+	return run
+}
+
+func TestNewNotification(t *testing.T) {
+	n := newNotification("/usr/local/bin/backup.sh", []string{"--full", "/data"}, &runner.Run{
+		ID:          "run1",
+		Job:         "backup",
+		Description: "nightly backup",
+		Output:      "disk full\n",
+		ExitCode:    2,
+		Err:         errors.New("exit status 2"),
+	})
+	if n.Job != "backup" {
+		t.Errorf("Job = %q, want the run's job name", n.Job)
+	}
+	if n.Command != "/usr/local/bin/backup.sh" || n.Args != "--full /data" {
+		t.Errorf("Command, Args = %q, %q", n.Command, n.Args)
+	}
+	if n.Output != "disk full\n" {
+		t.Errorf("Output = %q, want the run's output", n.Output)
+	}
+	if n.Event != eventFailure || n.Success || n.Error != "exit status 2" {
+		t.Errorf("Event, Success, Error = %q, %v, %q", n.Event, n.Success, n.Error)
+	}
+
+	timedOut := newNotification("backup.sh", nil, testRun("run2", fmt.Errorf("%w after 1m0s", runner.ErrTimedOut)))
+	if timedOut.Event != eventTimeout {
+		t.Errorf("Event = %q for a timed-out run, want %q", timedOut.Event, eventTimeout)
+	}
+}
+
+func TestNotifyRun(t *testing.T) {
+	failed := errors.New("exit status 1")
+	tests := []struct {
+		name      string
+		threshold int
+		runs      []error
+		want      []string
+	}{
+		{
+			name:      "every run",
+			threshold: 1,
+			runs:      []error{nil, failed, nil},
+			want:      []string{eventSuccess, eventFailure, eventRecovery},
+		},
+		{
+			name:      "failures below threshold",
+			threshold: 3,
+			runs:      []error{failed, failed, nil},
+			want:      []string{eventSuccess},
+		},
+		{
+			name:      "failures reaching threshold",
+			threshold: 2,
+			runs:      []error{failed, failed, failed, nil, nil},
+			want:      []string{eventFailure, eventFailure, eventRecovery, eventSuccess},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fake fakeNotifier
+			recovery := &recoveryTracker{threshold: tt.threshold}
+			for i, err := range tt.runs {
+				notifyRun(&fake, recovery, newNotification("backup.sh", nil, testRun(fmt.Sprint("run", i), err)), true)
+			}
+			if got := fake.events(); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("events = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotifiersFanOut(t *testing.T) {
+	var a, b fakeNotifier
+	notifiers{&a, nopNotifier{}, &b}.notify(newNotification("backup.sh", nil, testRun("run1", nil)))
+	if len(a.sent) != 1 || len(b.sent) != 1 {
+		t.Fatalf("notifiers received %d and %d notifications, want 1 each", len(a.sent), len(b.sent))
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []notification
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n notification
+		if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+			t.Errorf("invalid webhook body: %v", err)
+		}
+		mu.Lock()
+		received = append(received, n)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	w, err := newWebhookNotifier(srv.URL, "failure,recovery", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recovery := &recoveryTracker{threshold: 1}
+	for i, err := range []error{nil, errors.New("exit status 1"), nil} {
+		notifyRun(w, recovery, newNotification("backup.sh", nil, testRun(fmt.Sprint("run", i), err)), true)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 2 {
+		t.Fatalf("webhook received %d notifications, want 2", len(received))
+	}
+	if n := received[0]; n.Event != eventFailure || n.RunID != "run1" || n.Job != "backup" || n.Output != "copied 3 files\n" {
+		t.Errorf("first notification = %+v", n)
+	}
+	if n := received[1]; n.Event != eventRecovery || !n.Recovered || n.Failures != 0 {
+		t.Errorf("second notification = %+v", n)
+	}
+}
+
+func TestNewWebhookNotifierRejectsUnknownEvent(t *testing.T) {
+	if _, err := newWebhookNotifier("https://example.com", "failure,crash", false); err == nil {
+		t.Error("newWebhookNotifier accepted event 'crash'")
+	}
+}
+
+func TestWebhookNotifierOnRecovery(t *testing.T) {
+	recovered := notification{Event: eventRecovery, Success: true, Recovered: true}
+	for _, onRecovery := range []bool{false, true} {
+		w, err := newWebhookNotifier("https://example.com", "failure", onRecovery)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := w.wants(recovered); got != onRecovery {
+			t.Errorf("with onRecovery %t, wants(recovery) = %t", onRecovery, got)
+		}
+	}
+}
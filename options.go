@@ -0,0 +1,523 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/focela/cronx/pkg/runner"
+)
+
+// configError is a setting that keeps cronx from starting. main logs msg
+// with err as the error.
+type configError struct {
+	msg string
+	err error
+}
+
+func (e *configError) Error() string {
+	return e.msg + ": " + e.err.Error()
+}
+
+func (e *configError) Unwrap() error {
+	return e.err
+}
+
+// invalidFlag returns a configError for an invalid value of the named flag.
+func invalidFlag(name string, err error) error {
+	return &configError{msg: "invalid --" + name, err: err}
+}
+
+// configureLogging replaces logger with one built from the logging flags.
+func configureLogging(cfg *config) error {
+	if err := logLevel.UnmarshalText([]byte(cfg.level)); err != nil {
+		return invalidFlag("log-level", err)
+	}
+	if cfg.logFlushInterval < 0 {
+		return invalidFlag("log-flush-interval", errors.New("must not be negative"))
+	}
+	if !validLogFormat(cfg.logFormat) {
+		return invalidFlag("log-format", errors.New("must be json, text or logfmt"))
+	}
+	if cfg.logDedup < 0 {
+		return invalidFlag("log-dedup", errors.New("must not be negative"))
+	}
+	sinks := []logSink{{target: "stdout", format: cfg.logFormat, level: logLevel}}
+	if len(cfg.logSinks) > 0 {
+		sinks = nil
+		for _, spec := range cfg.logSinks {
+			sink, err := parseLogSink(spec, logLevel)
+			if err != nil {
+				return invalidFlag("log-sink", err)
+			}
+			sinks = append(sinks, sink)
+		}
+	}
+	if err := setupLogging(sinks, cfg.logFlushInterval); err != nil {
+		return &configError{msg: "failed to set up logging", err: err}
+	}
+	if cfg.quiet {
+		logger = slog.New(quietHandler{logger.Handler()})
+	}
+	if cfg.logIncludeHost {
+		host, err := os.Hostname()
+		if err != nil {
+			return &configError{msg: "failed to resolve hostname", err: err}
+		}
+		logger = logger.With("host", host, "pid", os.Getpid())
+	}
+	if cfg.description != "" {
+		logger = logger.With("description", cfg.description)
+	}
+	if cfg.logDedup > 0 {
+		dedup := newDedupHandler(logger.Handler(), cfg.logDedup)
+		logger = slog.New(dedup)
+		flush := flushLogs
+		flushLogs = func() {
+			dedup.state.flush()
+			flush()
+		}
+	}
+	return nil
+}
+
+// validate checks the flags that need nothing but their own values, so
+// that a bad setting is reported before any file is read or opened.
+func validate(cfg *config) error {
+	if _, err := runner.NewParser(cfg.syntax); err != nil {
+		return invalidFlag("cron-syntax", err)
+	}
+	if cfg.adaptiveInterval {
+		if cfg.noopExitCode < 0 {
+			return invalidFlag("adaptive-interval", errors.New("requires --noop-exit-code"))
+		}
+		if cfg.maxInterval <= 0 {
+			return invalidFlag("adaptive-interval", errors.New("requires --max-interval"))
+		}
+	}
+	if cfg.jitter < 0 {
+		return invalidFlag("jitter", errors.New("must not be negative"))
+	}
+	if cfg.timeoutPercent < 0 || cfg.timeoutPercent > 100 {
+		return invalidFlag("timeout-percent", errors.New("must be between 0 and 100"))
+	}
+	if cfg.timeoutGrace < 0 {
+		return invalidFlag("timeout-grace", errors.New("must not be negative"))
+	}
+	if cfg.expandUndefined != "empty" && cfg.expandUndefined != "error" {
+		return invalidFlag("expand-undefined", fmt.Errorf("expected empty or error, got '%s'", cfg.expandUndefined))
+	}
+	for _, v := range cfg.envVars {
+		if err := validateEnvVar(v); err != nil {
+			return invalidFlag("env", err)
+		}
+	}
+	if cfg.publishCommand != "" && cfg.publishTimeout <= 0 {
+		return invalidFlag("publish-timeout", errors.New("must be positive"))
+	}
+	for _, addr := range cfg.waitForAddrs {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return invalidFlag("wait-for", err)
+		}
+	}
+	if cfg.waitTimeout <= 0 {
+		return invalidFlag("wait-timeout", errors.New("must be positive"))
+	}
+	if cfg.cwdPerRun != "" {
+		if info, err := os.Stat(cfg.cwdPerRun); err != nil || !info.IsDir() {
+			return invalidFlag("command-cwd-per-run", fmt.Errorf("not a directory: %s", cfg.cwdPerRun))
+		}
+	}
+	if cfg.probeCommand != "" {
+		if cfg.probeInterval <= 0 {
+			return invalidFlag("probe-interval", errors.New("must be positive"))
+		}
+		if cfg.probeFailures < 1 {
+			return invalidFlag("probe-failures", errors.New("must be at least 1"))
+		}
+	}
+	if cfg.failureThreshold < 1 {
+		return invalidFlag("failure-threshold", errors.New("must be at least 1"))
+	}
+	if cfg.httpAddr != "" && cfg.historySize < 1 {
+		return invalidFlag("history-size", errors.New("must be at least 1"))
+	}
+	if cfg.exitOnIdle < 0 {
+		return invalidFlag("exit-on-idle", errors.New("must not be negative"))
+	}
+	if cfg.reportInterval < 0 {
+		return invalidFlag("report-interval", errors.New("must not be negative"))
+	}
+	if cfg.maxLifetime < 0 {
+		return invalidFlag("max-lifetime", errors.New("must not be negative"))
+	}
+	if cfg.admissionURL != "" && cfg.admissionTimeout <= 0 {
+		return invalidFlag("admission-timeout", errors.New("must be positive"))
+	}
+	if cfg.dailyBudget < 0 {
+		return invalidFlag("daily-runtime-budget", errors.New("must not be negative"))
+	}
+	if cfg.stateFile != "" && cfg.dailyBudget == 0 {
+		return invalidFlag("state-file", errors.New("requires --daily-runtime-budget"))
+	}
+	if cfg.stateFile != "" {
+		// Checked now rather than when the first run finishes.
+		if err := checkStateFile(cfg.stateFile); err != nil {
+			return invalidFlag("state-file", err)
+		}
+	}
+	if cfg.stdinURL != "" {
+		if cfg.stdinTimeout <= 0 {
+			return invalidFlag("stdin-timeout", errors.New("must be positive"))
+		}
+		if cfg.stdinOnError != "fail" && cfg.stdinOnError != "skip" {
+			return invalidFlag("stdin-on-error", fmt.Errorf("expected fail or skip, got '%s'", cfg.stdinOnError))
+		}
+	}
+	if cfg.parseJSONOutput && !cfg.logOutputOnFailure && !cfg.logOutputLines {
+		return invalidFlag("parse-json-output", errors.New("requires --log-output or --log-output-on-failure"))
+	}
+	if cfg.logOutputLines && cfg.logOutputOnFailure {
+		return invalidFlag("log-output", errors.New("cannot be combined with --log-output-on-failure"))
+	}
+	if cfg.logDir != "" && (cfg.logOutputOnFailure || cfg.logOutputLines) {
+		return invalidFlag("log-dir", errors.New("cannot be combined with --log-output or --log-output-on-failure"))
+	}
+	return nil
+}
+
+// buildOptions turns a validated config and the schedule, command and
+// arguments in positionals into the runner options and the scheduler
+// state their hooks feed. The returned scheduler has no runner yet.
+func buildOptions(cfg *config, positionals []string) (runner.Options, *scheduler, error) {
+	location, err := loadLocation(cfg.tz, cfg.utc)
+	if err != nil {
+		return runner.Options{}, nil, invalidFlag("tz", err)
+	}
+	var holidays map[string]bool
+	if cfg.holidaysFile != "" {
+		if holidays, err = loadHolidays(cfg.holidaysFile); err != nil {
+			return runner.Options{}, nil, invalidFlag("holidays", err)
+		}
+	}
+
+	codes, err := parseSuccessCodes(cfg.successCodes)
+	if err != nil {
+		return runner.Options{}, nil, invalidFlag("success-codes", err)
+	}
+	var expectRe, failRe *regexp.Regexp
+	if cfg.expectOutput != "" {
+		if expectRe, err = regexp.Compile(cfg.expectOutput); err != nil {
+			return runner.Options{}, nil, invalidFlag("expect-output-regex", err)
+		}
+	}
+	if cfg.failOutput != "" {
+		if failRe, err = regexp.Compile(cfg.failOutput); err != nil {
+			return runner.Options{}, nil, invalidFlag("fail-output-regex", err)
+		}
+	}
+	var adaptive *runner.AdaptiveInterval
+	if cfg.adaptiveInterval {
+		adaptive = &runner.AdaptiveInterval{NoopCode: cfg.noopExitCode, Min: cfg.minInterval, Max: cfg.maxInterval}
+	}
+	warnSignal, err := parseSignal(cfg.timeoutWarnSignal)
+	if err != nil {
+		return runner.Options{}, nil, invalidFlag("timeout-warn-signal", err)
+	}
+	var env []string
+	if cfg.envFile != "" {
+		if env, err = loadEnvFile(cfg.envFile); err != nil {
+			return runner.Options{}, nil, invalidFlag("env-file", err)
+		}
+	}
+	env = append(env, cfg.envVars...)
+	var passthrough []string
+	if cfg.envPassthrough != "" {
+		passthrough = []string{}
+		for key := range strings.SplitSeq(cfg.envPassthrough, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				passthrough = append(passthrough, key)
+			}
+		}
+	}
+
+	// --slack-webhook on its own keeps selecting Slack.
+	specs := cfg.notifierSpecs
+	if len(specs) == 0 && cfg.slackWebhook != "" {
+		specs = stringList{"slack"}
+	}
+	var notify notifiers
+	for _, spec := range specs {
+		var nt notifier
+		switch url, isWebhook := strings.CutPrefix(spec, "webhook="); {
+		case spec == "none":
+			nt = nopNotifier{}
+		case spec == "slack":
+			if cfg.slackWebhook == "" {
+				return runner.Options{}, nil, invalidFlag("notifier", errors.New("slack requires --slack-webhook"))
+			}
+			if nt, err = newSlackNotifier(cfg.slackWebhook, cfg.slackOn, cfg.slackTemplate, cfg.notifyOnRecovery); err != nil {
+				return runner.Options{}, nil, &configError{msg: "invalid slack settings", err: err}
+			}
+		case isWebhook && url != "":
+			if nt, err = newWebhookNotifier(url, cfg.notifyEvents, cfg.notifyOnRecovery); err != nil {
+				return runner.Options{}, nil, &configError{msg: "invalid webhook settings", err: err}
+			}
+		default:
+			return runner.Options{}, nil, invalidFlag("notifier", fmt.Errorf("expected slack, webhook=URL or none, got '%s'", spec))
+		}
+		notify = append(notify, nt)
+	}
+
+	s := &scheduler{
+		failures: newHistory(recentFailures),
+		skips:    &skipCounter{},
+	}
+	if cfg.publishCommand != "" {
+		if s.publish, err = newPublisher(cfg.publishCommand, cfg.publishTimeout); err != nil {
+			return runner.Options{}, nil, invalidFlag("publish-command", err)
+		}
+	}
+
+	var cpus []int
+	if cfg.cpuAffinity != "" {
+		if cpus, err = parseCPUList(cfg.cpuAffinity); err != nil {
+			return runner.Options{}, nil, invalidFlag("cpu-affinity", err)
+		}
+	}
+
+	if cfg.probeCommand != "" {
+		argv, err := splitWords(cfg.probeCommand)
+		if err == nil && len(argv) == 0 {
+			err = errors.New("empty command")
+		}
+		if err != nil {
+			return runner.Options{}, nil, invalidFlag("probe-command", err)
+		}
+		s.liveness = &probe{argv: argv, interval: cfg.probeInterval, failures: cfg.probeFailures}
+	}
+
+	recovery := &recoveryTracker{threshold: cfg.failureThreshold}
+	if cfg.httpAddr != "" {
+		s.hist = newHistory(cfg.historySize)
+	}
+
+	command := positionals[1]
+	args := positionals[2:]
+	// The job is named after the user's command, not the --wrapper
+	// executable that ends up running it.
+	name := filepath.Base(command)
+	if cfg.argsFile != "" {
+		extra, err := loadArgsFile(cfg.argsFile)
+		if err != nil {
+			return runner.Options{}, nil, invalidFlag("args-file", err)
+		}
+		args = append(args, extra...)
+	}
+	if cfg.wrapper != "" {
+		if command, args, err = applyWrapper(cfg.wrapper, command, args); err != nil {
+			return runner.Options{}, nil, invalidFlag("wrapper", err)
+		}
+	}
+	if cfg.commandAllowlist != "" {
+		allowed, err := loadAllowlist(cfg.commandAllowlist)
+		if err != nil {
+			return runner.Options{}, nil, invalidFlag("command-allowlist", err)
+		}
+		commands := []string{positionals[1], command}
+		if s.liveness != nil {
+			commands = append(commands, s.liveness.argv[0])
+		}
+		if s.publish != nil {
+			commands = append(commands, s.publish.command)
+		}
+		for _, c := range commands {
+			if err := allowed.check(c); err != nil {
+				return runner.Options{}, nil, &configError{msg: "command rejected", err: err}
+			}
+		}
+	}
+
+	if cfg.exitOnIdle > 0 {
+		s.idle = time.NewTimer(cfg.exitOnIdle)
+	}
+	if cfg.historyExport != "" {
+		if s.export, err = newRunReport(cfg.historyExport); err != nil {
+			return runner.Options{}, nil, invalidFlag("history-export", err)
+		}
+	}
+	if cfg.reportInterval > 0 {
+		s.report = newReporter(cfg.reportInterval)
+	}
+
+	var admit func(*runner.Run) error
+	if cfg.admissionURL != "" {
+		webhook := &admissionWebhook{
+			url:      cfg.admissionURL,
+			command:  command,
+			args:     args,
+			failOpen: cfg.admissionFailOpen,
+			timeout:  cfg.admissionTimeout,
+			client:   &http.Client{},
+		}
+		admit = webhook.admit
+	}
+	var budget *runtimeBudget
+	if cfg.dailyBudget > 0 {
+		if budget, err = newRuntimeBudget(cfg.dailyBudget, location, cfg.stateFile); err != nil {
+			return runner.Options{}, nil, invalidFlag("state-file", err)
+		}
+		// The budget is checked first so exhausted days cost no webhook call.
+		webhookAdmit := admit
+		admit = func(run *runner.Run) error {
+			if err := budget.admit(run); err != nil {
+				return err
+			}
+			if webhookAdmit != nil {
+				return webhookAdmit(run)
+			}
+			return nil
+		}
+	}
+
+	var stdin func(*runner.Run) (io.Reader, error)
+	if cfg.stdinURL != "" {
+		fetcher := &stdinFetcher{
+			url:     cfg.stdinURL,
+			timeout: cfg.stdinTimeout,
+			skip:    cfg.stdinOnError == "skip",
+			client:  &http.Client{},
+		}
+		stdin = fetcher.fetch
+	}
+
+	// Nil writers keep the runner's default of passing output through.
+	var stdout, stderr io.Writer
+	if cfg.logOutputOnFailure {
+		stdout, stderr = io.Discard, io.Discard
+	}
+	// Each run gets its own line writers so overlapping runs never mix
+	// their partial lines.
+	var outputLines func(*runner.Run) (io.Writer, io.Writer)
+	if cfg.logOutputLines {
+		var outLevel, errLevel slog.Level
+		if err := outLevel.UnmarshalText([]byte(cfg.stdoutLevel)); err != nil {
+			return runner.Options{}, nil, invalidFlag("stdout-level", err)
+		}
+		if err := errLevel.UnmarshalText([]byte(cfg.stderrLevel)); err != nil {
+			return runner.Options{}, nil, invalidFlag("stderr-level", err)
+		}
+		outputLines = func(run *runner.Run) (io.Writer, io.Writer) {
+			return &lineWriter{stream: "stdout", level: outLevel, runID: run.ID, parseJSON: cfg.parseJSONOutput},
+				&lineWriter{stream: "stderr", level: errLevel, runID: run.ID, parseJSON: cfg.parseJSONOutput}
+		}
+	}
+	if cfg.logDir != "" {
+		// Named after the job so that it matches CRONX_JOB.
+		if s.jobOutput, err = openJobLog(cfg.logDir, name); err != nil {
+			return runner.Options{}, nil, invalidFlag("log-dir", err)
+		}
+		stdout, stderr = s.jobOutput, s.jobOutput
+	}
+
+	opts := runner.Options{
+		Schedule:           positionals[0],
+		Name:               name,
+		Command:            command,
+		Args:               args,
+		Description:        cfg.description,
+		Syntax:             cfg.syntax,
+		Location:           location,
+		Offset:             cfg.scheduleOffset,
+		AlignFirstRun:      cfg.alignFirstRun,
+		SuccessCodes:       codes,
+		Adaptive:           adaptive,
+		StderrIsFailure:    cfg.stderrIsFailure,
+		ExpectOutput:       expectRe,
+		FailOutput:         failRe,
+		Jitter:             cfg.jitter,
+		JitterSeed:         cfg.jitterSeed,
+		TimeoutPercent:     cfg.timeoutPercent,
+		TimeoutIsSuccess:   cfg.timeoutAsSuccess,
+		TimeoutGrace:       cfg.timeoutGrace,
+		OutputStallTimeout: cfg.outputStallTimeout,
+		WarnSignal:         warnSignal,
+		CPUAffinity:        cpus,
+		Chroot:             cfg.chroot,
+		Env:                env,
+		EnvPassthrough:     passthrough,
+		ExpandEnv:          cfg.expandEnv,
+		ExpandStrict:       cfg.expandUndefined == "error",
+		Scratch:            cfg.scratch,
+		RunDirBase:         cfg.cwdPerRun,
+		CleanupRunDir:      cfg.cleanupCwd,
+		MaxLoad:            cfg.maxLoad,
+		WorkingDays:        cfg.workingSchedule,
+		Holidays:           holidays,
+		Stdout:             stdout,
+		Stderr:             stderr,
+		Output:             outputLines,
+		CaptureOutput:      s.hist != nil || cfg.logOutputOnFailure || len(notify) > 0,
+		PTY:                cfg.pty,
+		Admit:              admit,
+		Stdin:              stdin,
+		OnSkip: func(run *runner.Run, reason runner.SkipReason) {
+			n := s.skips.add(reason)
+			logger.Info("run skip counted", "run_id", run.ID, "reason", reason, "skipped_"+string(reason), n)
+		},
+		OnStart: func(run *runner.Run) {
+			if s.idle != nil {
+				s.idle.Reset(cfg.exitOnIdle)
+			}
+			if s.publish != nil {
+				s.publish.publish(publishStart, run)
+			}
+		},
+		OnError: func(run *runner.Run, _ error) {
+			if s.publish != nil {
+				s.publish.publish(publishError, run)
+			}
+		},
+		OnComplete: func(run *runner.Run, _ int, _ time.Duration) {
+			if run.Err != nil {
+				s.failures.add(newRunRecord(run))
+				if cfg.logOutputOnFailure {
+					logCommandOutput(run, cfg.parseJSONOutput)
+				}
+			}
+			if s.hist != nil {
+				s.hist.add(newRunRecord(run))
+			}
+			if s.report != nil {
+				s.report.record(run.Err == nil)
+			}
+			if s.export != nil {
+				s.export.add(newRunRecord(run))
+			}
+			if s.publish != nil {
+				s.publish.publish(publishComplete, run)
+			}
+			if budget != nil {
+				budget.record(run)
+			}
+			notifyRun(notify, recovery, newNotification(command, args, run), cfg.notifyOnRecovery)
+		},
+		Logger: logger,
+	}
+	return opts, s, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		args []string
+		msg  string
+	}{
+		{nil, ""},
+		{[]string{"--jitter", "-1s"}, "invalid --jitter"},
+		{[]string{"--timeout-percent", "101"}, "invalid --timeout-percent"},
+		{[]string{"--adaptive-interval", "--max-interval", "1h"}, "invalid --adaptive-interval"},
+		{[]string{"--env", "NOEQUALS"}, "invalid --env"},
+		{[]string{"--wait-for", "localhost"}, "invalid --wait-for"},
+		{[]string{"--state-file", "budget.json"}, "invalid --state-file"},
+		{[]string{"--daily-runtime-budget", "1h", "--state-file", "/nonexistent/budget.json"}, "invalid --state-file"},
+		{[]string{"--daily-runtime-budget", "1h", "--state-file", "/"}, "invalid --state-file"},
+		{[]string{"--stdin-url", "http://127.0.0.1/", "--stdin-on-error", "retry"}, "invalid --stdin-on-error"},
+		{[]string{"--parse-json-output"}, "invalid --parse-json-output"},
+		{[]string{"--log-output", "--log-output-on-failure"}, "invalid --log-output"},
+		{[]string{"--log-dir", "logs", "--log-output"}, "invalid --log-dir"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			fs := flag.NewFlagSet("cronx", flag.ContinueOnError)
+			cfg := defineFlags(fs)
+			if err := parseFlags(fs, tt.args); err != nil {
+				t.Fatal(err)
+			}
+			err := validate(cfg)
+			if tt.msg == "" {
+				if err != nil {
+					t.Fatalf("validate: %v", err)
+				}
+				return
+			}
+			var ce *configError
+			if !errors.As(err, &ce) || ce.msg != tt.msg {
+				t.Errorf("validate = %v, want %q", err, tt.msg)
+			}
+		})
+	}
+}
+
+func TestBuildOptionsNamesJobBeforeWrapper(t *testing.T) {
+	fs := flag.NewFlagSet("cronx", flag.ContinueOnError)
+	cfg := defineFlags(fs)
+	if err := parseFlags(fs, []string{"--wrapper", "nice -n 10 {cmd}"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := validate(cfg); err != nil {
+		t.Fatal(err)
+	}
+	opts, _, err := buildOptions(cfg, []string{"@daily", "/usr/local/bin/backup", "--full"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Command != "nice" {
+		t.Errorf("Command = %q, want the wrapper", opts.Command)
+	}
+	if opts.Name != "backup" {
+		t.Errorf("Name = %q, want the wrapped command's base name", opts.Name)
+	}
+}
+
+func TestBuildOptionsNamesJobLogBeforeWrapper(t *testing.T) {
+	dir := t.TempDir()
+	fs := flag.NewFlagSet("cronx", flag.ContinueOnError)
+	cfg := defineFlags(fs)
+	if err := parseFlags(fs, []string{"--wrapper", "timeout 30 {cmd}", "--log-dir", dir}); err != nil {
+		t.Fatal(err)
+	}
+	_, s, err := buildOptions(cfg, []string{"@daily", "backup"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.jobOutput.f.Close()
+	if _, err := os.Stat(filepath.Join(dir, "backup.log")); err != nil {
+		t.Errorf("job log not named after the wrapped command: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+//go:build linux
+
+package runner
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// maxCPUs is the number of CPUs an affinity mask can describe.
+const maxCPUs = 1024
+
+// affinitySupported reports whether startPinned can pin commands.
+const affinitySupported = true
+
+// checkCPUs returns an error unless every CPU in cpus is in the affinity
+// mask of the current process, as reported by sched_getaffinity(2). Unlike
+// a comparison with runtime.NumCPU, this honors taskset and cgroup
+// cpusets, which may leave a process with non-contiguous CPU numbers.
+func checkCPUs(cpus []int) error {
+	var mask [maxCPUs / 64]uint64
+	_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_GETAFFINITY,
+		0, unsafe.Sizeof(mask), uintptr(unsafe.Pointer(&mask)))
+	if errno != 0 {
+		return fmt.Errorf("failed to read CPU affinity: %w", errno)
+	}
+	for _, cpu := range cpus {
+		if mask[cpu/64]&(1<<(cpu%64)) == 0 {
+			return fmt.Errorf("CPU %d is not available to this process", cpu)
+		}
+	}
+	return nil
+}
+
+// startPinned starts cmd already pinned to cpus. The mask is set on a
+// locked thread before the fork, which the child inherits, so the command
+// never runs elsewhere. The thread stays locked so that it exits with its
+// goroutine instead of returning to the scheduler with the mask.
+func startPinned(cmd *exec.Cmd, cpus []int) error {
+	errc := make(chan error, 1)
+	go func() {
+		runtime.LockOSThread()
+		if err := setAffinity(0, cpus); err != nil {
+			errc <- fmt.Errorf("failed to set CPU affinity: %w", err)
+			return
+		}
+		errc <- cmd.Start()
+	}()
+	return <-errc
+}
+
+// setAffinity pins the thread or process pid, 0 for the calling thread, to
+// cpus with sched_setaffinity(2). Threads and children created afterwards
+// inherit the mask.
+func setAffinity(pid int, cpus []int) error {
+	var mask [maxCPUs / 64]uint64
+	for _, cpu := range cpus {
+		mask[cpu/64] |= 1 << (cpu % 64)
+	}
+	_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETAFFINITY,
+		uintptr(pid), unsafe.Sizeof(mask), uintptr(unsafe.Pointer(&mask)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+//go:build linux
+
+package runner
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCPUAffinityAppliesFromExec(t *testing.T) {
+	// The highest CPU available to the test, so that the pinned mask
+	// differs from the inherited one whenever there is more than one.
+	cpu := -1
+	for c := range maxCPUs {
+		if checkCPUs([]int{c}) == nil {
+			cpu = c
+		}
+	}
+	var out bytes.Buffer
+	// The shell is replaced by grep, so the mask it reports is the one
+	// the command was started with.
+	r, err := New(Options{
+		Schedule:    "@every 1s",
+		Command:     "sh",
+		Args:        []string{"-c", "exec grep Cpus_allowed_list /proc/self/status"},
+		CPUAffinity: []int{cpu},
+		Stdout:      &out,
+		Stderr:      io.Discard,
+		Logger:      slog.New(slog.DiscardHandler),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run := r.RunAt(time.Now()); run.Err != nil {
+		t.Fatal(run.Err)
+	}
+	_, got, _ := strings.Cut(strings.TrimSpace(out.String()), ":")
+	if got = strings.TrimSpace(got); got != strconv.Itoa(cpu) {
+		t.Errorf("command ran on CPUs %q, want %d", got, cpu)
+	}
+}
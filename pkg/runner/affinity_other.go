@@ -0,0 +1,29 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+//go:build !linux
+
+package runner
+
+import "os/exec"
+
+// maxCPUs is the number of CPUs an affinity mask can describe.
+const maxCPUs = 1024
+
+// affinitySupported reports whether startPinned can pin commands.
+const affinitySupported = false
+
+// checkCPUs accepts any CPU, since affinity is ignored.
+func checkCPUs(cpus []int) error {
+	return nil
+}
+
+// startPinned starts cmd without pinning it; New warns that CPU affinity
+// is ignored.
+func startPinned(cmd *exec.Cmd, cpus []int) error {
+	return cmd.Start()
+}
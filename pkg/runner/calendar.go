@@ -0,0 +1,21 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package runner
+
+import "time"
+
+// dateLayout is the format of Options.Holidays keys.
+const dateLayout = "2006-01-02"
+
+// isWorkingDay reports whether t falls on a weekday that is not a holiday.
+func isWorkingDay(t time.Time, holidays map[string]bool) bool {
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	return !holidays[t.Format(dateLayout)]
+}
@@ -0,0 +1,41 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+//go:build unix
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// checkChroot reports whether commands can be confined to dir.
+func checkChroot(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("invalid chroot: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("invalid chroot: %s is not a directory", dir)
+	}
+	if os.Geteuid() != 0 {
+		return errors.New("invalid chroot: requires root privileges")
+	}
+	return nil
+}
+
+// setChroot makes cmd change its root directory to dir before it starts.
+func setChroot(cmd *exec.Cmd, dir string) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Chroot = dir
+}
@@ -0,0 +1,23 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+//go:build windows
+
+package runner
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// checkChroot always fails; Windows has no chroot.
+func checkChroot(dir string) error {
+	return errors.New("invalid chroot: not supported on windows")
+}
+
+// setChroot is never reached because New rejects a chroot on Windows.
+func setChroot(cmd *exec.Cmd, dir string) {}
@@ -0,0 +1,45 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package runner
+
+import "time"
+
+// Clock tells time for a Runner. Tests can substitute a fake clock to
+// drive fire times, jitter, working-day checks, timeouts and durations
+// without real waits.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After sends the current time on the returned channel once d has
+	// elapsed.
+	After(d time.Duration) <-chan time.Time
+	// AfterFunc calls f in its own goroutine once d has elapsed.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a timer created by Clock.AfterFunc. *time.Timer implements it.
+type Timer interface {
+	// Stop prevents the timer from firing and reports whether it was
+	// still pending.
+	Stop() bool
+	// Reset restarts the timer to fire after d and reports whether it
+	// was still pending.
+	Reset(d time.Duration) bool
+}
+
+// systemClock is the Clock backed by package time.
+type systemClock struct{}
+
+// Now implements Clock.
+func (systemClock) Now() time.Time { return time.Now() }
+
+// After implements Clock.
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// AfterFunc implements Clock.
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer { return time.AfterFunc(d, f) }
@@ -0,0 +1,270 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package runner
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock that only moves when Advance is called.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// fakeTimer is a pending After channel or AfterFunc callback.
+type fakeTimer struct {
+	clock *fakeClock
+	when  time.Time
+	ch    chan time.Time
+	f     func()
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+// Now implements Clock.
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After implements Clock.
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	t := &fakeTimer{clock: c, ch: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t.ch
+}
+
+// AfterFunc implements Clock.
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	t := &fakeTimer{clock: c, f: f}
+	t.Reset(d)
+	return t
+}
+
+// Advance moves the clock forward by d and fires every timer due by then.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	now := c.now
+	var due, pending []*fakeTimer
+	for _, t := range c.timers {
+		if t.when.After(now) {
+			pending = append(pending, t)
+		} else {
+			due = append(due, t)
+		}
+	}
+	c.timers = pending
+	c.mu.Unlock()
+
+	for _, t := range due {
+		t.fire(now)
+	}
+}
+
+// pending returns the number of timers that have not fired or been
+// stopped.
+func (c *fakeClock) pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// waitForTimers waits until at least n timers are pending, so that an
+// Advance is not lost on a goroutine that has yet to start waiting.
+func (c *fakeClock) waitForTimers(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for c.pending() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d timers pending, want %d", c.pending(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (t *fakeTimer) fire(now time.Time) {
+	if t.ch != nil {
+		t.ch <- now
+		return
+	}
+	go t.f()
+}
+
+// Stop implements Timer.
+func (t *fakeTimer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, p := range c.timers {
+		if p == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Reset implements Timer.
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	wasPending := t.Stop()
+	c := t.clock
+	c.mu.Lock()
+	t.when = c.now.Add(d)
+	now := c.now
+	if d > 0 {
+		c.timers = append(c.timers, t)
+	}
+	c.mu.Unlock()
+	if d <= 0 {
+		t.fire(now)
+	}
+	return wasPending
+}
+
+// start is a Monday morning, so working-day checks pass.
+var start = time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+
+// newTestRunner returns a Runner driven by clock that reports each
+// finished run on the returned channel.
+func newTestRunner(t *testing.T, clock Clock, opts Options) (*Runner, <-chan *Run) {
+	t.Helper()
+	done := make(chan *Run, 16)
+	if opts.Command == "" {
+		opts.Command = "true"
+	}
+	opts.Clock = clock
+	opts.Location = time.UTC
+	opts.Stdout, opts.Stderr = io.Discard, io.Discard
+	opts.Logger = slog.New(slog.DiscardHandler)
+	opts.OnComplete = func(run *Run, exitCode int, duration time.Duration) { done <- run }
+	r, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, done
+}
+
+// waitRun returns the next finished run.
+func waitRun(t *testing.T, done <-chan *Run) *Run {
+	t.Helper()
+	select {
+	case run := <-done:
+		return run
+	case <-time.After(10 * time.Second):
+		t.Fatal("run did not finish")
+		return nil
+	}
+}
+
+// expectNoRun fails if a run finishes within a short real wait.
+func expectNoRun(t *testing.T, done <-chan *Run) {
+	t.Helper()
+	select {
+	case run := <-done:
+		t.Fatalf("unexpected run scheduled at %s", run.Scheduled)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestFakeClockFireCount(t *testing.T) {
+	clock := newFakeClock(start)
+	r, done := newTestRunner(t, clock, Options{Schedule: "@every 1m"})
+	r.Start()
+	defer r.Stop()
+
+	const fires = 5
+	for i := 1; i <= fires; i++ {
+		clock.waitForTimers(t, 1)
+		clock.Advance(time.Minute)
+		run := waitRun(t, done)
+		if want := start.Add(time.Duration(i) * time.Minute); !run.Scheduled.Equal(want) {
+			t.Errorf("run %d scheduled at %s, want %s", i, run.Scheduled, want)
+		}
+		if run.Err != nil {
+			t.Errorf("run %d failed: %v", i, run.Err)
+		}
+	}
+
+	clock.waitForTimers(t, 1)
+	clock.Advance(30 * time.Second)
+	expectNoRun(t, done)
+	if want := start.Add((fires + 1) * time.Minute); !r.Next().Equal(want) {
+		t.Errorf("Next() = %s, want %s", r.Next(), want)
+	}
+}
+
+func TestFakeClockCronExpression(t *testing.T) {
+	clock := newFakeClock(start)
+	r, done := newTestRunner(t, clock, Options{Schedule: "*/15 * * * *"})
+	r.Start()
+	defer r.Stop()
+
+	// Each Advance reaches exactly the next quarter hour.
+	var fired []time.Time
+	for range 4 {
+		clock.waitForTimers(t, 1)
+		clock.Advance(15 * time.Minute)
+		fired = append(fired, waitRun(t, done).Scheduled)
+	}
+	for i, got := range fired {
+		if want := start.Add(time.Duration(i+1) * 15 * time.Minute); !got.Equal(want) {
+			t.Errorf("fire %d at %s, want %s", i, got, want)
+		}
+	}
+}
+
+func TestFakeClockJitter(t *testing.T) {
+	clock := newFakeClock(start)
+	r, done := newTestRunner(t, clock, Options{Schedule: "@every 1h", Jitter: 10 * time.Minute, JitterSeed: 1})
+	r.Start()
+	defer r.Stop()
+
+	clock.waitForTimers(t, 1)
+	clock.Advance(time.Hour)
+	// The loop waits for the next fire time and the run for its jitter.
+	clock.waitForTimers(t, 2)
+	expectNoRun(t, done)
+	clock.Advance(10 * time.Minute)
+	run := waitRun(t, done)
+	if delay := run.Started.Sub(run.Scheduled); delay <= 0 || delay > 10*time.Minute {
+		t.Errorf("run started %s after its fire time, want within the 10m jitter", delay)
+	}
+}
+
+func TestFakeClockTimeout(t *testing.T) {
+	clock := newFakeClock(start)
+	r, done := newTestRunner(t, clock, Options{
+		Schedule:       "@every 1h",
+		Command:        "sleep",
+		Args:           []string{"60"},
+		TimeoutPercent: 50,
+	})
+
+	go r.RunAt(start)
+	// Only the run's timeout is pending.
+	clock.waitForTimers(t, 1)
+	clock.Advance(30 * time.Minute)
+	run := waitRun(t, done)
+	if !errors.Is(run.Err, ErrTimedOut) {
+		t.Fatalf("run error = %v, want ErrTimedOut", run.Err)
+	}
+	if run.Duration != 30*time.Minute {
+		t.Errorf("Duration = %s, want the 30m measured by the clock", run.Duration)
+	}
+}
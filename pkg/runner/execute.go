@@ -0,0 +1,512 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// outputTailSize is how many trailing bytes of output a run keeps.
+	outputTailSize = 4096
+	// waitDelay bounds how long a killed command's output is drained,
+	// since grandchildren may keep its pipes open.
+	waitDelay = 2 * time.Second
+)
+
+// Run describes a single execution of the command. Its identifying
+// fields are exported to the command as CRONX_* environment variables.
+type Run struct {
+	// ID uniquely identifies the run (CRONX_RUN_ID).
+	ID string
+	// Job is Options.Name (CRONX_JOB).
+	Job string
+	// Description is Options.Description.
+	Description string
+	// Attempt counts executions of this run, starting at 1 (CRONX_ATTEMPT).
+	Attempt int
+	// Scheduled is when the scheduler fired the run (CRONX_SCHEDULED_TIME).
+	Scheduled time.Time
+	// Timeout kills the command when non-zero.
+	Timeout time.Duration
+	// Started is when the command was started.
+	Started time.Time
+	// ExitCode is the command's exit code, or -1 if it did not exit normally.
+	ExitCode int
+	// Duration is how long the command ran.
+	Duration time.Duration
+	// UserTime and SystemTime are the CPU time the command used.
+	UserTime   time.Duration
+	SystemTime time.Duration
+	// MaxRSS is the command's peak resident set size in KiB, or zero
+	// where the platform does not report it.
+	MaxRSS int64
+	// Output holds the tail of the command's output when
+	// Options.CaptureOutput is set.
+	Output string
+	// Err is why the run failed, or nil if it succeeded.
+	Err error
+
+	// stdin is the command's standard input from Options.Stdin.
+	stdin io.Reader
+}
+
+// execute runs the command for run, redirecting stdout/stderr.
+// The command is killed when ctx is cancelled or a non-zero timeout elapses.
+// Exit codes listed in Options.SuccessCodes are not reported as errors.
+func (r *Runner) execute(ctx context.Context, run *Run) error {
+	command, args := r.opts.Command, r.opts.Args
+	r.log.Info("executing command", "command", command, "args", args, "run_id", run.ID)
+	// Set before anything can fail so that a run which never got to start
+	// its command is not reported as exiting 0.
+	run.ExitCode = -1
+	started := r.clock.Now()
+	run.Started = started
+
+	timeout := run.Timeout
+	runCtx := ctx
+	if timeout > 0 {
+		// Timed by the Clock rather than context.WithTimeout so that a
+		// fake clock can expire it.
+		var cancel context.CancelCauseFunc
+		runCtx, cancel = context.WithCancelCause(runCtx)
+		defer cancel(nil)
+		expire := r.clock.AfterFunc(timeout, func() { cancel(ErrTimedOut) })
+		defer expire.Stop()
+	}
+
+	stallTimeout := r.opts.OutputStallTimeout
+	var cancelStalled context.CancelCauseFunc
+	if stallTimeout > 0 {
+		runCtx, cancelStalled = context.WithCancelCause(runCtx)
+		defer cancelStalled(nil)
+	}
+
+	// Options.Env comes last so that user settings win over run metadata.
+	env := append(InheritedEnv(r.opts.EnvPassthrough), runEnv(run)...)
+	env = append(env, r.opts.Env...)
+	if r.opts.ExpandEnv {
+		var err error
+		if args, err = expandArgs(args, env, r.opts.ExpandStrict); err != nil {
+			return err
+		}
+	}
+
+	stdout, stderr := r.opts.Stdout, r.opts.Stderr
+	if r.opts.Output != nil {
+		stdout, stderr = r.opts.Output(run)
+		defer closeOutput(r.log, stdout, stderr)
+	}
+	cmd := exec.CommandContext(runCtx, command, args...)
+	cmd.Stdin = run.stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.Env = env
+	var output *tailBuffer
+	if r.opts.CaptureOutput {
+		output = &tailBuffer{max: outputTailSize}
+		defer func() { run.Output = output.String() }()
+		cmd.Stdout = io.MultiWriter(stdout, output)
+		cmd.Stderr = io.MultiWriter(stderr, output)
+	}
+	var stall Timer
+	if stallTimeout > 0 {
+		stall = r.clock.AfterFunc(stallTimeout, func() {
+			r.log.Warn("command output stalled", "command", command, "run_id", run.ID, "stall_timeout", stallTimeout.String())
+			cancelStalled(errStalled)
+		})
+		stall.Stop()
+		defer stall.Stop()
+		cmd.Stdout = activityWriter{cmd.Stdout, stall, stallTimeout}
+		cmd.Stderr = activityWriter{cmd.Stderr, stall, stallTimeout}
+	}
+	var stderrUsed *usedWriter
+	if r.opts.StderrIsFailure {
+		stderrUsed = &usedWriter{w: cmd.Stderr}
+		cmd.Stderr = stderrUsed
+	}
+	var matcher *outputMatcher
+	var stdoutLines, stderrLines *matchWriter
+	if r.opts.ExpectOutput != nil || r.opts.FailOutput != nil {
+		matcher = &outputMatcher{expect: r.opts.ExpectOutput, fail: r.opts.FailOutput}
+		stdoutLines = &matchWriter{w: cmd.Stdout, m: matcher}
+		stderrLines = &matchWriter{w: cmd.Stderr, m: matcher}
+		cmd.Stdout, cmd.Stderr = stdoutLines, stderrLines
+	}
+	if r.opts.Chroot != "" {
+		setChroot(cmd, r.opts.Chroot)
+	}
+	var master, tty *os.File
+	drainPTY := func() {}
+	if r.opts.PTY {
+		var err error
+		if master, tty, err = openPTY(); err != nil {
+			return fmt.Errorf("failed to allocate pty: %w", err)
+		}
+		defer master.Close()
+		defer tty.Close()
+		out := cmd.Stdout
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = tty, tty, tty
+		setPTY(cmd)
+		copied := make(chan struct{})
+		go func() {
+			// Reading fails once the command and its children close the
+			// terminal, or when the master is closed below.
+			io.Copy(out, master)
+			close(copied)
+		}()
+		drainPTY = sync.OnceFunc(func() {
+			select {
+			case <-copied:
+			case <-time.After(waitDelay):
+			}
+			master.Close()
+			<-copied
+		})
+		defer drainPTY()
+	}
+	setProcessGroup(cmd)
+	cmd.WaitDelay = waitDelay
+	cmd.Cancel = func() error {
+		r.log.Warn("killing command", "command", command, "reason", context.Cause(runCtx))
+		if err := killProcessGroup(cmd.Process); err != nil {
+			r.log.Error("failed to kill command", "command", command, "run_id", run.ID, "error", err)
+			return err
+		}
+		return nil
+	}
+
+	if r.opts.Scratch {
+		dir, err := os.MkdirTemp("", "cronx-scratch-")
+		if err != nil {
+			return fmt.Errorf("failed to create scratch directory: %w", err)
+		}
+		defer func() {
+			if err := os.RemoveAll(dir); err != nil {
+				r.log.Error("failed to remove scratch directory", "dir", dir, "error", err)
+			}
+		}()
+		cmd.Env = append(cmd.Env, "CRONX_SCRATCH="+dir)
+	}
+
+	if r.opts.RunDirBase != "" {
+		dir := filepath.Join(r.opts.RunDirBase, run.ID)
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create working directory: %w", err)
+		}
+		if r.opts.CleanupRunDir {
+			defer func() {
+				if err := os.RemoveAll(dir); err != nil {
+					r.log.Error("failed to remove working directory", "dir", dir, "error", err)
+				}
+			}()
+		}
+		cmd.Dir = dir
+	}
+
+	if stall != nil {
+		stall.Reset(stallTimeout)
+	}
+	start := cmd.Start
+	if len(r.opts.CPUAffinity) > 0 {
+		start = func() error { return startPinned(cmd, r.opts.CPUAffinity) }
+	}
+	if err := start(); err != nil {
+		return fmt.Errorf("command execution failed: %w", err)
+	}
+	if tty != nil {
+		// Only the command keeps the terminal open, so its exit ends the copy.
+		tty.Close()
+	}
+	defer r.track(run)()
+
+	grace := r.opts.TimeoutGrace
+	if timeout > 0 && grace > 0 && grace < timeout {
+		warn := r.clock.AfterFunc(timeout-grace, func() {
+			r.log.Warn("sending timeout warning", "command", command, "signal", r.opts.WarnSignal, "grace", grace.String())
+			if err := signalProcessGroup(cmd.Process, r.opts.WarnSignal); err != nil {
+				r.log.Error("failed to send timeout warning", "error", err)
+			}
+		})
+		defer warn.Stop()
+	}
+
+	err := cmd.Wait()
+	// Output must be complete before it can be classified.
+	drainPTY()
+	if matcher != nil {
+		stdoutLines.flush()
+		stderrLines.flush()
+	}
+	code := cmd.ProcessState.ExitCode()
+	run.ExitCode = code
+	run.Duration = r.clock.Now().Sub(started)
+	run.UserTime = cmd.ProcessState.UserTime()
+	run.SystemTime = cmd.ProcessState.SystemTime()
+	attrs := []any{
+		"command", command,
+		"run_id", run.ID,
+		"exit_code", code,
+		"wait", started.Sub(run.Scheduled).String(),
+		"duration", run.Duration.String(),
+		"cpu_user_ms", run.UserTime.Milliseconds(),
+		"cpu_sys_ms", run.SystemTime.Milliseconds(),
+	}
+	if rss, ok := maxRSS(cmd.ProcessState); ok {
+		run.MaxRSS = rss
+		attrs = append(attrs, "max_rss_kb", rss)
+	}
+	r.log.Info("command finished", attrs...)
+
+	if errors.Is(context.Cause(runCtx), errStalled) {
+		return fmt.Errorf("command produced no output for %s", stallTimeout)
+	}
+	if errors.Is(context.Cause(runCtx), ErrTimedOut) {
+		if r.opts.TimeoutIsSuccess {
+			r.log.Warn("command timed out, treating as success", "command", command, "run_id", run.ID, "timeout", timeout.String())
+			return nil
+		}
+		return fmt.Errorf("%w after %s", ErrTimedOut, timeout)
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return fmt.Errorf("command execution failed: %w", err)
+	}
+	if !r.successCodes[code] {
+		// Exit code 0 leaves err nil when 0 is not a success code.
+		if err == nil {
+			return fmt.Errorf("command exited with code %d, not in the success codes", code)
+		}
+		return fmt.Errorf("command execution failed: %w", err)
+	}
+	if stderrUsed != nil && stderrUsed.used.Load() {
+		r.log.Warn("run classified as failed", "command", command, "run_id", run.ID, "reason", "wrote to stderr")
+		return errors.New("command wrote to stderr")
+	}
+	if matcher != nil {
+		if reason, pattern := matcher.verdict(); reason != "" {
+			r.log.Warn("run classified as failed", "command", command, "run_id", run.ID, "reason", reason, "pattern", pattern)
+			return fmt.Errorf("command %s '%s'", reason, pattern)
+		}
+	}
+	return nil
+}
+
+// maxMatchLine bounds the line buffered for output patterns; longer lines
+// are matched in pieces.
+const maxMatchLine = 64 << 10
+
+// outputMatcher checks each line of a run's output against
+// Options.ExpectOutput and Options.FailOutput.
+type outputMatcher struct {
+	expect, fail *regexp.Regexp
+
+	expected atomic.Bool
+	failed   atomic.Bool
+}
+
+// match records whether line matches either pattern.
+func (m *outputMatcher) match(line []byte) {
+	if m.expect != nil && !m.expected.Load() && m.expect.Match(line) {
+		m.expected.Store(true)
+	}
+	if m.fail != nil && !m.failed.Load() && m.fail.Match(line) {
+		m.failed.Store(true)
+	}
+}
+
+// verdict returns why the output fails the run and the pattern at
+// fault, or "" if it passes.
+func (m *outputMatcher) verdict() (reason, pattern string) {
+	if m.fail != nil && m.failed.Load() {
+		return "output matched failure pattern", m.fail.String()
+	}
+	if m.expect != nil && !m.expected.Load() {
+		return "output did not match expected pattern", m.expect.String()
+	}
+	return "", ""
+}
+
+// matchWriter passes output through and feeds it to an outputMatcher a
+// line at a time. Each stream needs its own matchWriter.
+type matchWriter struct {
+	w       io.Writer
+	m       *outputMatcher
+	partial []byte
+}
+
+// Write implements io.Writer.
+func (w *matchWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.partial = append(w.partial, p[:n]...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		// Terminals end lines with \r\n; keep $ anchors working.
+		w.m.match(bytes.TrimSuffix(w.partial[:i], []byte("\r")))
+		w.partial = w.partial[i+1:]
+	}
+	if len(w.partial) > maxMatchLine {
+		w.flush()
+	}
+	return n, err
+}
+
+// flush matches a trailing line without a newline.
+func (w *matchWriter) flush() {
+	if len(w.partial) > 0 {
+		w.m.match(w.partial)
+		w.partial = w.partial[:0]
+	}
+}
+
+// closeOutput closes the writers returned by Options.Output that
+// implement io.Closer, once each.
+func closeOutput(log *slog.Logger, stdout, stderr io.Writer) {
+	for i, w := range []io.Writer{stdout, stderr} {
+		c, ok := w.(io.Closer)
+		// Comparing interfaces panics for uncomparable dynamic types.
+		if !ok || (i == 1 && reflect.TypeOf(w).Comparable() && w == stdout) {
+			continue
+		}
+		if err := c.Close(); err != nil {
+			log.Error("failed to close command output", "error", err)
+		}
+	}
+}
+
+// usedWriter records whether anything was written through it.
+type usedWriter struct {
+	w    io.Writer
+	used atomic.Bool
+}
+
+// Write implements io.Writer.
+func (u *usedWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		u.used.Store(true)
+	}
+	return u.w.Write(p)
+}
+
+// ErrTimedOut is wrapped by the error of a run killed by its timeout.
+var ErrTimedOut = errors.New("command timed out")
+
+// errStalled is the cause of killing a command whose output stalled.
+var errStalled = errors.New("output stalled")
+
+// activityWriter restarts a stall timer on every write.
+type activityWriter struct {
+	w       io.Writer
+	timer   Timer
+	timeout time.Duration
+}
+
+// Write implements io.Writer.
+func (a activityWriter) Write(p []byte) (int, error) {
+	a.timer.Reset(a.timeout)
+	return a.w.Write(p)
+}
+
+// InheritedEnv returns the part of cronx's environment a command
+// inherits: all of it when passthrough is nil, otherwise only the
+// variables named in passthrough.
+func InheritedEnv(passthrough []string) []string {
+	if passthrough == nil {
+		return os.Environ()
+	}
+	var env []string
+	for _, kv := range os.Environ() {
+		k, _, _ := strings.Cut(kv, "=")
+		if slices.Contains(passthrough, k) {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
+
+// runEnv returns the CRONX_* variables describing run.
+func runEnv(run *Run) []string {
+	return []string{
+		"CRONX_JOB=" + run.Job,
+		"CRONX_RUN_ID=" + run.ID,
+		"CRONX_SCHEDULED_TIME=" + run.Scheduled.Format(time.RFC3339),
+		"CRONX_ATTEMPT=" + strconv.Itoa(run.Attempt),
+	}
+}
+
+// expandArgs replaces $VAR and ${VAR} in args with values from env, where
+// later entries win. Undefined variables expand to the empty string, or
+// are an error when strict is set.
+func expandArgs(args, env []string, strict bool) ([]string, error) {
+	vars := make(map[string]string, len(env))
+	for _, kv := range env {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			vars[k] = v
+		}
+	}
+	var undefined []string
+	expanded := make([]string, len(args))
+	for i, arg := range args {
+		expanded[i] = os.Expand(arg, func(name string) string {
+			v, ok := vars[name]
+			if !ok {
+				undefined = append(undefined, name)
+			}
+			return v
+		})
+	}
+	if strict && len(undefined) > 0 {
+		return nil, fmt.Errorf("undefined variables in arguments: %s", strings.Join(undefined, ", "))
+	}
+	return expanded, nil
+}
+
+// tailBuffer is a concurrency-safe writer that keeps only the last max bytes.
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+	max int
+}
+
+// Write implements io.Writer.
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.buf = append(t.buf, p...)
+	if over := len(t.buf) - t.max; over > 0 {
+		t.buf = append(t.buf[:0], t.buf[over:]...)
+	}
+	return len(p), nil
+}
+
+// String returns the buffered tail.
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return string(t.buf)
+}
@@ -0,0 +1,18 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+//go:build !linux
+
+package runner
+
+// loadSupported reports whether loadAverage can read the system load.
+const loadSupported = false
+
+// loadAverage is a no-op; New warns that MaxLoad is ignored.
+func loadAverage() (float64, error) {
+	return 0, nil
+}
@@ -0,0 +1,21 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+//go:build unix && !linux
+
+package runner
+
+import (
+	"errors"
+	"syscall"
+)
+
+// groupAlive reports whether group pgid still has members. Without a
+// portable way to tell zombies apart, unreaped ones count as members.
+func groupAlive(pgid int) bool {
+	return !errors.Is(syscall.Kill(-pgid, 0), syscall.ESRCH)
+}
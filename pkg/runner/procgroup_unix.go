@@ -0,0 +1,67 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+//go:build unix
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+const (
+	// killRetries is how often SIGKILL is resent to a surviving group.
+	killRetries = 5
+	// killRetryInterval is the pause before checking a group again.
+	killRetryInterval = 100 * time.Millisecond
+)
+
+// setProcessGroup starts cmd in a process group of its own so that a kill
+// also reaches the children of intermediate shells. A command on a PTY
+// already leads its own session and group.
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	if !cmd.SysProcAttr.Setsid {
+		cmd.SysProcAttr.Setpgid = true
+	}
+}
+
+// signalProcessGroup sends sig to the process group led by p, so that
+// children of intermediate shells receive it too.
+func signalProcessGroup(p *os.Process, sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return p.Signal(sig)
+	}
+	return syscall.Kill(-p.Pid, s)
+}
+
+// killProcessGroup sends SIGKILL to the process group led by p and resends
+// it while members remain, since a member may be forking as it dies. It
+// returns an error if members are still alive after the retries.
+func killProcessGroup(p *os.Process) error {
+	for range killRetries {
+		if err := syscall.Kill(-p.Pid, syscall.SIGKILL); err != nil {
+			if errors.Is(err, syscall.ESRCH) {
+				return nil
+			}
+			return err
+		}
+		time.Sleep(killRetryInterval)
+		if !groupAlive(p.Pid) {
+			return nil
+		}
+	}
+	return fmt.Errorf("process group %d survived %d kills", p.Pid, killRetries)
+}
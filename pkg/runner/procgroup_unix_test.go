@@ -0,0 +1,101 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+//go:build unix
+
+package runner
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readPID waits for a shell to write a PID to path.
+func readPID(t *testing.T, path string) int {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		data, err := os.ReadFile(path)
+		if err == nil && strings.HasSuffix(string(data), "\n") {
+			pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+			if err != nil {
+				t.Fatalf("invalid PID file: %v", err)
+			}
+			return pid
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("command never wrote the PID")
+	return 0
+}
+
+func TestKillReachesChildTrappingSIGTERM(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "group.pid")
+	// The shell leading the group and its background child both ignore
+	// SIGTERM, so the timeout warning has no effect and only SIGKILL sent
+	// to the whole group ends them.
+	r, err := New(Options{
+		Schedule:       "@every 1s",
+		Command:        "sh",
+		Args:           []string{"-c", `trap "" TERM; echo $$ > "$1"; sh -c 'sleep 60' & wait`, "sh", pidFile},
+		TimeoutPercent: 30,
+		TimeoutGrace:   200 * time.Millisecond,
+		Stdout:         io.Discard,
+		Stderr:         io.Discard,
+		Logger:         slog.New(slog.DiscardHandler),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	run := r.RunAt(start)
+	if !errors.Is(run.Err, ErrTimedOut) {
+		t.Fatalf("run error = %v, want ErrTimedOut", run.Err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("run took %s to be killed", elapsed)
+	}
+	if pgid := readPID(t, pidFile); groupAlive(pgid) {
+		t.Errorf("process group %d still has live members after the kill", pgid)
+	}
+}
+
+func TestTimeoutWarningReachesGroup(t *testing.T) {
+	dir := t.TempDir()
+	pidFile := filepath.Join(dir, "child.pid")
+	warned := filepath.Join(dir, "warned")
+	// Only the background child reacts to SIGTERM; the shell leading the
+	// group ignores it from the moment the child has been started, since
+	// a signal ignored on entry cannot be trapped.
+	r, err := New(Options{
+		Schedule:       "@every 2s",
+		Command:        "sh",
+		Args:           []string{"-c", `sh -c 'trap "touch \"$1\"; exit 0" TERM; while :; do sleep 0.05; done' sh "$2" & trap "" TERM; echo $! > "$1"; wait`, "sh", pidFile, warned},
+		TimeoutPercent: 50,
+		TimeoutGrace:   700 * time.Millisecond,
+		Stdout:         io.Discard,
+		Stderr:         io.Discard,
+		Logger:         slog.New(slog.DiscardHandler),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.RunAt(time.Now())
+	readPID(t, pidFile)
+	if _, err := os.Stat(warned); err != nil {
+		t.Errorf("child did not receive the timeout warning: %v", err)
+	}
+}
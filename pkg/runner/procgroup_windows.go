@@ -0,0 +1,28 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+//go:build windows
+
+package runner
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup is a no-op; Windows has no Unix process groups.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// signalProcessGroup signals the command itself.
+func signalProcessGroup(p *os.Process, sig os.Signal) error {
+	return p.Signal(sig)
+}
+
+// killProcessGroup terminates the command itself.
+func killProcessGroup(p *os.Process) error {
+	return p.Kill()
+}
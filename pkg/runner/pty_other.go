@@ -0,0 +1,27 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+//go:build !linux
+
+package runner
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// ptySupported reports whether openPTY can allocate terminals.
+const ptySupported = false
+
+// openPTY is never reached because New rejects PTY here.
+func openPTY() (master, slave *os.File, err error) {
+	return nil, nil, errors.New("pseudo-terminals are not supported")
+}
+
+// setPTY is never reached because New rejects PTY here.
+func setPTY(cmd *exec.Cmd) {}
@@ -0,0 +1,40 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package runner
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// randSource is a concurrency-safe random source shared by all
+// randomized behavior, so a single seed makes runs reproducible.
+type randSource struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+// newRandSource returns a source seeded with seed, or a randomly
+// seeded one when seed is zero.
+func newRandSource(seed uint64) *randSource {
+	if seed == 0 {
+		return &randSource{r: rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))}
+	}
+	return &randSource{r: rand.New(rand.NewPCG(seed, seed))}
+}
+
+// duration returns a random duration in [0, max).
+func (s *randSource) duration(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Duration(s.r.Int64N(int64(max)))
+}
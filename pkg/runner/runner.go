@@ -0,0 +1,674 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+// Package runner runs a command on a cron schedule with graceful shutdown.
+// It is the engine behind the cronx command and can be embedded in other
+// Go programs.
+package runner
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// ErrSkip is wrapped by hook errors that skip a run rather than fail it.
+var ErrSkip = errors.New("run skipped")
+
+// SkipReason says why a fire time did not start the command.
+type SkipReason string
+
+// Reasons passed to Options.OnSkip.
+const (
+	// SkipNonWorkingDay is a fire time on a weekend or holiday with
+	// Options.WorkingDays set.
+	SkipNonWorkingDay SkipReason = "non_working_day"
+	// SkipDenied is a run refused by Options.Admit.
+	SkipDenied SkipReason = "denied"
+	// SkipNoInput is a run whose Options.Stdin returned ErrSkip.
+	SkipNoInput SkipReason = "no_input"
+	// SkipHighLoad is a run skipped because the load average exceeded
+	// Options.MaxLoad.
+	SkipHighLoad SkipReason = "high_load"
+	// SkipShutdown is a fire time reached after Stop was called.
+	SkipShutdown SkipReason = "shutdown"
+)
+
+// farFuture is how far away a first fire time may be before New warns.
+const farFuture = 366 * 24 * time.Hour
+
+// Options configures a Runner. Schedule and Command are required; the
+// zero value of every other field keeps the default behavior.
+type Options struct {
+	// Schedule is a cron expression, descriptor, @every interval or
+	// @after delay, see Parse.
+	Schedule string
+	// Command is the program to run, looked up in PATH.
+	Command string
+	// Args are passed to Command unchanged.
+	Args []string
+	// Name identifies the job in CRONX_JOB. Defaults to the base name
+	// of Command.
+	Name string
+	// Description is a human-readable label copied to every Run.
+	Description string
+
+	// Syntax selects the schedule parser, see NewParser. Defaults to
+	// "optional-seconds".
+	Syntax string
+	// Location is the time zone the schedule is evaluated in. Defaults
+	// to time.Local.
+	Location *time.Location
+	// Offset shifts every fire time and may be negative.
+	Offset time.Duration
+	// AlignFirstRun snaps the first run of an @every schedule to the next
+	// multiple of its interval, see AlignFirst.
+	AlignFirstRun bool
+
+	// SuccessCodes lists exit codes treated as success. Defaults to 0.
+	SuccessCodes []int
+	// StderrIsFailure fails a run that exits successfully but wrote
+	// anything to stderr.
+	StderrIsFailure bool
+	// ExpectOutput fails a run that exits successfully but printed no
+	// line matching it on stdout or stderr.
+	ExpectOutput *regexp.Regexp
+	// FailOutput fails a run that exits successfully but printed a line
+	// matching it on stdout or stderr.
+	FailOutput *regexp.Regexp
+	// Jitter is the maximum random delay added before each run.
+	Jitter time.Duration
+	// JitterSeed makes jitter reproducible; zero picks a random seed.
+	JitterSeed uint64
+	// TimeoutPercent kills a run exceeding this percentage of the
+	// schedule interval; zero disables the limit.
+	TimeoutPercent int
+	// OutputStallTimeout kills a run that writes nothing to stdout or
+	// stderr for this long; zero disables the check.
+	OutputStallTimeout time.Duration
+	// TimeoutIsSuccess reports a run killed by its timeout as successful.
+	// The timeout is still logged.
+	TimeoutIsSuccess bool
+	// TimeoutGrace is how long before the timeout WarnSignal is sent.
+	TimeoutGrace time.Duration
+	// WarnSignal asks a command to finish before the timeout kills it.
+	// Defaults to SIGTERM.
+	WarnSignal os.Signal
+
+	// CPUAffinity pins each command to these CPUs. Only supported on
+	// Linux; elsewhere it is ignored with a warning.
+	CPUAffinity []int
+	// Chroot confines each command to this root directory. Unix only;
+	// it requires root privileges, and Command must exist at the same
+	// path inside it.
+	Chroot string
+	// Env holds KEY=VALUE entries added to the inherited environment;
+	// later entries win.
+	Env []string
+	// Adaptive, when set, stretches an @every schedule while runs find
+	// no work and snaps it back once one does.
+	Adaptive *AdaptiveInterval
+	// EnvPassthrough, when non-nil, limits the environment inherited from
+	// cronx to the named variables. Env and the CRONX_* variables are
+	// set regardless.
+	EnvPassthrough []string
+	// ExpandEnv expands $VAR and ${VAR} in Args before each run, using
+	// the command's environment including Env and the run's CRONX_*
+	// variables.
+	ExpandEnv bool
+	// ExpandStrict fails a run whose Args reference an undefined
+	// variable instead of expanding it to the empty string.
+	ExpandStrict bool
+	// Scratch gives each run a temporary directory, exported as
+	// CRONX_SCRATCH and removed after the run.
+	Scratch bool
+	// RunDirBase, when set, gives each run a fresh working directory
+	// named after its ID under this directory.
+	RunDirBase string
+	// CleanupRunDir removes the per-run working directory afterwards.
+	CleanupRunDir bool
+	// MaxLoad skips runs while the 1-minute load average is above it;
+	// zero disables the check. Only supported on Linux; elsewhere it is
+	// ignored with a warning.
+	MaxLoad float64
+	// WorkingDays skips runs on weekends and Holidays.
+	WorkingDays bool
+	// Holidays holds YYYY-MM-DD dates skipped by WorkingDays.
+	Holidays map[string]bool
+
+	// Stdout and Stderr receive the command's output. They default to
+	// os.Stdout and os.Stderr.
+	Stdout io.Writer
+	Stderr io.Writer
+	// Output, when set, returns the stdout and stderr writers of a single
+	// run instead of Stdout and Stderr, so that overlapping runs do not
+	// share them. Writers implementing io.Closer are closed once the
+	// command has exited, before OnComplete is called.
+	Output func(run *Run) (stdout, stderr io.Writer)
+	// CaptureOutput keeps the tail of each run's output in Run.Output.
+	CaptureOutput bool
+	// PTY runs the command on a pseudo-terminal whose output goes to
+	// Stdout, so commands behave as if run interactively. Linux only;
+	// it cannot be combined with Stdin or StderrIsFailure.
+	PTY bool
+
+	// Admit is called before each run, after any jitter; a non-nil error
+	// skips the run.
+	Admit func(run *Run) error
+	// Stdin is called after Admit and returns the command's standard
+	// input. An error wrapping ErrSkip skips the run; any other error
+	// fails it without starting the command.
+	Stdin func(run *Run) (io.Reader, error)
+	// OnSkip is called when a fire time passes without starting the
+	// command.
+	OnSkip func(run *Run, reason SkipReason)
+	// OnStart is called just before the command is started.
+	OnStart func(run *Run)
+	// OnComplete is called after every run, successful or not, once
+	// OnError has returned.
+	OnComplete func(run *Run, exitCode int, duration time.Duration)
+	// OnError is called when a run fails, before OnComplete.
+	OnError func(run *Run, err error)
+
+	// Logger receives the runner's logs. Defaults to slog.Default().
+	Logger *slog.Logger
+	// Clock drives the schedule. Defaults to the system clock.
+	Clock Clock
+}
+
+// AdaptiveInterval configures Options.Adaptive.
+type AdaptiveInterval struct {
+	// NoopCode is the exit code of a run that found nothing to do. It
+	// always counts as success.
+	NoopCode int
+	// Min is the interval after a run that did work or failed. Defaults
+	// to the @every interval.
+	Min time.Duration
+	// Max bounds the interval, which doubles after each no-op run.
+	Max time.Duration
+}
+
+// Runner schedules and executes a single command.
+//
+// The OnStart, OnComplete and OnError hooks are called synchronously on
+// the goroutine executing the run, so a single run's hooks never overlap
+// and run in order. Runs of the same Runner may overlap, however, so hooks
+// must be safe for concurrent use. Stop waits for running hooks to return;
+// a slow hook delays shutdown but never the start of other runs.
+type Runner struct {
+	opts         Options
+	log          *slog.Logger
+	clock        Clock
+	wg           sync.WaitGroup
+	rand         *randSource
+	successCodes map[int]bool
+
+	// sched is the parsed schedule, or adaptive when it stretches one;
+	// fire wraps it with alignment and offset and is only used by the
+	// loop goroutine.
+	sched cron.Schedule
+	fire  cron.Schedule
+	// adaptive is the stretched schedule with Options.Adaptive, else nil.
+	adaptive *adaptiveSchedule
+	// done is closed when the loop started by Start returns.
+	done chan struct{}
+
+	// mu guards running, the runs whose command has been started, and
+	// next, the upcoming fire time.
+	mu      sync.Mutex
+	running map[string]*Run
+	next    time.Time
+
+	// ctx is cancelled by Stop so that pending runs are skipped.
+	ctx    context.Context
+	cancel context.CancelFunc
+	// killCtx is cancelled by Kill to kill running commands.
+	killCtx context.Context
+	kill    context.CancelFunc
+}
+
+// New validates opts, applies defaults and registers the schedule.
+// The scheduler does not fire until Start is called.
+func New(opts Options) (*Runner, error) {
+	if opts.Command == "" {
+		return nil, errors.New("no command given")
+	}
+	if opts.Jitter < 0 {
+		return nil, errors.New("invalid jitter: must not be negative")
+	}
+	if opts.TimeoutPercent < 0 || opts.TimeoutPercent > 100 {
+		return nil, errors.New("invalid timeout percent: must be between 0 and 100")
+	}
+	if opts.OutputStallTimeout < 0 {
+		return nil, errors.New("invalid output stall timeout: must not be negative")
+	}
+	if opts.TimeoutGrace < 0 {
+		return nil, errors.New("invalid timeout grace: must not be negative")
+	}
+	if opts.PTY {
+		switch {
+		case !ptySupported:
+			return nil, errors.New("invalid pty: only supported on Linux")
+		case opts.Stdin != nil:
+			return nil, errors.New("invalid pty: cannot be combined with stdin")
+		case opts.StderrIsFailure:
+			return nil, errors.New("invalid pty: stderr cannot be told apart from stdout")
+		}
+	}
+	for _, key := range opts.EnvPassthrough {
+		if key == "" || strings.Contains(key, "=") {
+			return nil, fmt.Errorf("invalid env passthrough key '%s'", key)
+		}
+	}
+	if opts.MaxLoad < 0 {
+		return nil, errors.New("invalid max load: must not be negative")
+	}
+	if opts.Chroot != "" {
+		if err := checkChroot(opts.Chroot); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Name == "" {
+		opts.Name = filepath.Base(opts.Command)
+	}
+	if opts.Syntax == "" {
+		opts.Syntax = DefaultSyntax
+	}
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+	if opts.SuccessCodes == nil {
+		opts.SuccessCodes = []int{0}
+	}
+	if opts.WarnSignal == nil {
+		opts.WarnSignal = syscall.SIGTERM
+	}
+	if opts.Stdout == nil {
+		opts.Stdout = os.Stdout
+	}
+	if opts.Stderr == nil {
+		opts.Stderr = os.Stderr
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	if opts.Clock == nil {
+		opts.Clock = systemClock{}
+	}
+
+	for _, cpu := range opts.CPUAffinity {
+		if cpu < 0 || cpu >= maxCPUs {
+			return nil, fmt.Errorf("invalid CPU %d", cpu)
+		}
+	}
+	if err := checkCPUs(opts.CPUAffinity); err != nil {
+		return nil, err
+	}
+	if len(opts.CPUAffinity) > 0 && !affinitySupported {
+		opts.Logger.Warn("CPU affinity is not supported on this platform and is ignored")
+	}
+	if opts.MaxLoad > 0 && !loadSupported {
+		opts.Logger.Warn("load average is not available on this platform; max load is ignored")
+	}
+
+	successCodes := make(map[int]bool)
+	for _, code := range opts.SuccessCodes {
+		if code < 0 || code > 255 {
+			return nil, fmt.Errorf("invalid exit code %d", code)
+		}
+		successCodes[code] = true
+	}
+	if opts.Adaptive != nil {
+		if code := opts.Adaptive.NoopCode; code < 0 || code > 255 {
+			return nil, fmt.Errorf("invalid no-op exit code %d", code)
+		}
+		successCodes[opts.Adaptive.NoopCode] = true
+	}
+
+	r := &Runner{
+		opts:         opts,
+		log:          opts.Logger,
+		clock:        opts.Clock,
+		rand:         newRandSource(opts.JitterSeed),
+		successCodes: successCodes,
+		running:      make(map[string]*Run),
+	}
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+	r.killCtx, r.kill = context.WithCancel(context.Background())
+
+	sched, err := r.parse()
+	if err != nil {
+		return nil, err
+	}
+	r.sched = sched
+
+	if a := opts.Adaptive; a != nil {
+		every, ok := sched.(cron.ConstantDelaySchedule)
+		switch {
+		case !ok:
+			return nil, errors.New("invalid adaptive interval: requires an @every schedule")
+		case opts.AlignFirstRun:
+			return nil, errors.New("invalid adaptive interval: cannot be combined with aligning the first run")
+		case a.Min < 0:
+			return nil, errors.New("invalid adaptive interval: minimum must not be negative")
+		}
+		lo := a.Min
+		if lo == 0 {
+			lo = every.Delay
+		}
+		if a.Max <= lo {
+			return nil, fmt.Errorf("invalid adaptive interval: maximum must be greater than %s", lo)
+		}
+		r.adaptive = &adaptiveSchedule{min: lo, max: a.Max, cur: lo}
+		sched = r.adaptive
+		r.sched = sched
+	}
+
+	r.log.Info("new cron scheduled", "schedule", opts.Schedule)
+
+	if opts.AlignFirstRun {
+		if _, ok := sched.(cron.ConstantDelaySchedule); !ok {
+			r.log.Warn("aligning the first run only affects @every schedules", "schedule", opts.Schedule)
+		}
+		sched = AlignFirst(sched, r.log)
+	}
+	r.fire = WithOffset(sched, opts.Offset)
+	r.log.Debug("scheduled job", "job", opts.Name, "schedule", opts.Schedule, "offset", opts.Offset.String())
+
+	return r, nil
+}
+
+// parse parses the schedule and warns about offsets that are not smaller
+// than the schedule interval.
+func (r *Runner) parse() (cron.Schedule, error) {
+	sched, err := Parse(r.opts.Schedule, r.opts.Syntax)
+	if err != nil {
+		return nil, err
+	}
+	logSchedule(r.log, r.opts.Schedule, sched)
+
+	// Expressions such as "0 0 30 2 *" parse but never match a date.
+	// Far-future schedules may be intentional, so these only warn.
+	now := r.clock.Now().In(r.opts.Location)
+	if alt := ambiguousFields(r.opts.Schedule, r.opts.Syntax); alt != nil {
+		r.log.Warn("schedule fields are ambiguous",
+			"schedule", r.opts.Schedule,
+			"chosen", "second minute hour dom month dow",
+			"chosen_next_run", sched.Next(now),
+			"alternative", "minute hour dom month dow year",
+			"alternative_next_run", alt.Next(now),
+		)
+	}
+	if next := sched.Next(now); next.IsZero() {
+		r.log.Warn("schedule never fires", "schedule", r.opts.Schedule)
+	} else if next.Sub(now) > farFuture {
+		r.log.Warn("schedule does not fire within a year", "schedule", r.opts.Schedule, "next_run", next)
+	}
+
+	if offsetTooLarge(sched, r.opts.Offset, r.clock.Now()) {
+		r.log.Warn("schedule offset is not smaller than the schedule interval",
+			"offset", r.opts.Offset.String(),
+			"interval", interval(sched, sched.Next(r.clock.Now())).String(),
+		)
+	}
+	return sched, nil
+}
+
+// Name returns the job name, Options.Name after defaults are applied.
+func (r *Runner) Name() string {
+	return r.opts.Name
+}
+
+// Next returns the next fire time, or the zero time before Start.
+func (r *Runner) Next() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.next
+}
+
+// Running returns the runs whose command is currently executing. Only
+// the fields set before the command started are filled in.
+func (r *Runner) Running() []Run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	runs := make([]Run, 0, len(r.running))
+	for _, run := range r.running {
+		runs = append(runs, Run{
+			ID:          run.ID,
+			Job:         run.Job,
+			Description: run.Description,
+			Attempt:     run.Attempt,
+			Scheduled:   run.Scheduled,
+			Timeout:     run.Timeout,
+			Started:     run.Started,
+		})
+	}
+	return runs
+}
+
+// track records run as executing until the returned function is called.
+func (r *Runner) track(run *Run) (untrack func()) {
+	r.mu.Lock()
+	r.running[run.ID] = run
+	r.mu.Unlock()
+	return func() {
+		r.mu.Lock()
+		delete(r.running, run.ID)
+		r.mu.Unlock()
+	}
+}
+
+// Start starts the scheduler in its own goroutine. It must be called at
+// most once.
+func (r *Runner) Start() {
+	r.done = make(chan struct{})
+	go r.loop()
+}
+
+// loop fires a run at every fire time until Stop is called. It replaces
+// cron.Cron, whose v3 scheduler reads time.Now and starts timers directly
+// and so cannot be driven by a Clock. Fire times still come from the
+// parsed cron.Schedule and, as with cron.Cron, the next one is computed
+// from the current time after each fire, so fire times missed while the
+// process was suspended are skipped rather than run late in a burst.
+func (r *Runner) loop() {
+	defer close(r.done)
+	for {
+		now := r.clock.Now().In(r.opts.Location)
+		next := r.fire.Next(now)
+		r.mu.Lock()
+		r.next = next
+		r.mu.Unlock()
+
+		if next.IsZero() {
+			r.log.Warn("schedule has no further fire times", "schedule", r.opts.Schedule)
+			<-r.ctx.Done()
+			return
+		}
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-r.clock.After(next.Sub(now)):
+			// Add before starting the goroutine so that Stop cannot
+			// miss a run that has already fired.
+			r.wg.Add(1)
+			if _, ok := r.sched.(afterSchedule); ok || r.adaptive != nil {
+				// The next fire time is computed from when this run
+				// finished, so runs never overlap.
+				r.run()
+			} else {
+				go r.run()
+			}
+		}
+	}
+}
+
+// Stop stops scheduling new runs and waits for running commands to finish.
+func (r *Runner) Stop() {
+	r.cancel()
+	r.log.Info("stopping scheduler")
+	if r.done != nil {
+		<-r.done
+	}
+	r.log.Info("waiting for running jobs to complete")
+	r.wg.Wait()
+}
+
+// Kill kills running commands without waiting for them; call Stop
+// afterwards to wait for them to exit.
+func (r *Runner) Kill() {
+	r.kill()
+}
+
+// skip reports a skipped run to Options.OnSkip.
+func (r *Runner) skip(run *Run, reason SkipReason) {
+	if r.opts.OnSkip != nil {
+		r.opts.OnSkip(run, reason)
+	}
+}
+
+// skipStopping reports run as skipped because the scheduler is stopping.
+func (r *Runner) skipStopping(run *Run) {
+	r.log.Info("skipping run during shutdown", "run_id", run.ID, "scheduled", run.Scheduled)
+	r.skip(run, SkipShutdown)
+}
+
+// run is the cron job executed at every fire time.
+func (r *Runner) run() {
+	defer r.wg.Done()
+
+	run := &Run{
+		ID:          rand.Text(),
+		Job:         r.opts.Name,
+		Description: r.opts.Description,
+		Attempt:     1,
+		Scheduled:   r.clock.Now(),
+	}
+	if r.opts.WorkingDays && !isWorkingDay(run.Scheduled.In(r.opts.Location), r.opts.Holidays) {
+		r.log.Info("skipping run on non-working day", "date", run.Scheduled.In(r.opts.Location).Format(dateLayout))
+		r.skip(run, SkipNonWorkingDay)
+		return
+	}
+	if r.opts.TimeoutPercent > 0 {
+		run.Timeout = interval(r.sched, run.Scheduled.In(r.opts.Location)) * time.Duration(r.opts.TimeoutPercent) / 100
+		r.log.Info("effective timeout", "timeout", run.Timeout.String(), "percent", r.opts.TimeoutPercent)
+	}
+
+	if delay := r.rand.duration(r.opts.Jitter); delay > 0 {
+		r.log.Info("delaying run", "jitter", delay.String())
+		select {
+		case <-r.ctx.Done():
+			r.skipStopping(run)
+			return
+		case <-r.clock.After(delay):
+		}
+	}
+
+	select {
+	case <-r.ctx.Done():
+		// The timer and Stop can race; never start work during the drain.
+		r.skipStopping(run)
+		return
+	default:
+		if r.opts.MaxLoad > 0 && loadSupported {
+			load, err := loadAverage()
+			if err != nil {
+				r.log.Error("failed to read load average", "error", err)
+			} else if load > r.opts.MaxLoad {
+				r.log.Warn("skipping run under high load", "run_id", run.ID, "load", load, "max_load", r.opts.MaxLoad)
+				r.skip(run, SkipHighLoad)
+				return
+			}
+		}
+		if r.opts.Admit != nil {
+			if err := r.opts.Admit(run); err != nil {
+				r.log.Warn("run denied", "run_id", run.ID, "reason", err)
+				r.skip(run, SkipDenied)
+				return
+			}
+		}
+		if r.opts.Stdin != nil {
+			stdin, err := r.opts.Stdin(run)
+			if errors.Is(err, ErrSkip) {
+				r.log.Warn("run skipped", "run_id", run.ID, "reason", err)
+				r.skip(run, SkipNoInput)
+				return
+			}
+			if err != nil {
+				run.ExitCode = -1
+				run.Err = fmt.Errorf("failed to prepare stdin: %w", err)
+			}
+			run.stdin = stdin
+		}
+		// Admission and stdin can take long enough for Stop to arrive.
+		if r.ctx.Err() != nil {
+			r.skipStopping(run)
+			return
+		}
+		r.start(run)
+		if r.adaptive != nil {
+			noop := run.Err == nil && run.ExitCode == r.opts.Adaptive.NoopCode
+			if r.adaptive.record(noop) {
+				r.log.Info("adaptive interval changed", "interval", r.adaptive.cur.String(), "noop", noop)
+			}
+		}
+	}
+}
+
+// RunAt executes the command once as the run scheduled at the given time
+// and returns it when it has finished. Unlike scheduled runs it has no
+// jitter and skips the working-day, load, Admit and Stdin steps; the
+// OnStart, OnError and OnComplete hooks are still called. It is meant
+// for backfilling missed fire times.
+func (r *Runner) RunAt(scheduled time.Time) *Run {
+	run := &Run{
+		ID:          rand.Text(),
+		Job:         r.opts.Name,
+		Description: r.opts.Description,
+		Attempt:     1,
+		Scheduled:   scheduled,
+	}
+	if r.opts.TimeoutPercent > 0 {
+		run.Timeout = interval(r.sched, run.Scheduled.In(r.opts.Location)) * time.Duration(r.opts.TimeoutPercent) / 100
+	}
+	r.start(run)
+	return run
+}
+
+// start executes run unless preparing it already failed, and reports it
+// to the hooks.
+func (r *Runner) start(run *Run) {
+	if run.Err == nil {
+		if r.opts.OnStart != nil {
+			r.opts.OnStart(run)
+		}
+		run.Err = r.execute(r.killCtx, run)
+	}
+	if run.Err != nil {
+		r.log.Error("command execution error", "error", run.Err)
+		if r.opts.OnError != nil {
+			r.opts.OnError(run, run.Err)
+		}
+	}
+	if r.opts.OnComplete != nil {
+		r.opts.OnComplete(run, run.ExitCode, run.Duration)
+	}
+}
@@ -0,0 +1,320 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package runner
+
+import (
+	"bytes"
+	"log/slog"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewValidatesOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"no command", Options{Schedule: "@every 1m"}},
+		{"invalid schedule", Options{Schedule: "every minute", Command: "true"}},
+		{"negative jitter", Options{Schedule: "@every 1m", Command: "true", Jitter: -time.Second}},
+		{"timeout percent above 100", Options{Schedule: "@every 1m", Command: "true", TimeoutPercent: 101}},
+		{"negative output stall timeout", Options{Schedule: "@every 1m", Command: "true", OutputStallTimeout: -time.Second}},
+		{"negative timeout grace", Options{Schedule: "@every 1m", Command: "true", TimeoutGrace: -time.Second}},
+		{"passthrough key with =", Options{Schedule: "@every 1m", Command: "true", EnvPassthrough: []string{"A=B"}}},
+		{"negative max load", Options{Schedule: "@every 1m", Command: "true", MaxLoad: -1}},
+		{"success code out of range", Options{Schedule: "@every 1m", Command: "true", SuccessCodes: []int{256}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Logger = slog.New(slog.DiscardHandler)
+			if _, err := New(tt.opts); err == nil {
+				t.Error("New accepted the options")
+			}
+		})
+	}
+}
+
+func TestNewDefaultsName(t *testing.T) {
+	r, err := New(Options{Schedule: "@every 1m", Command: "/usr/local/bin/backup", Logger: slog.New(slog.DiscardHandler)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Name() != "backup" {
+		t.Errorf("Name() = %q, want the command's base name", r.Name())
+	}
+}
+
+func TestRunAtHooks(t *testing.T) {
+	tests := []struct {
+		name         string
+		successCodes []int
+		wantHooks    []string
+		wantErr      bool
+	}{
+		{"failure", nil, []string{"start", "error", "complete"}, true},
+		{"success code", []int{0, 3}, []string{"start", "complete"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hooks []string
+			var completedCode int
+			r, err := New(Options{
+				Schedule:     "@every 1m",
+				Command:      "sh",
+				Args:         []string{"-c", "exit 3"},
+				SuccessCodes: tt.successCodes,
+				Logger:       slog.New(slog.DiscardHandler),
+				OnStart:      func(*Run) { hooks = append(hooks, "start") },
+				OnError:      func(*Run, error) { hooks = append(hooks, "error") },
+				OnComplete: func(_ *Run, exitCode int, _ time.Duration) {
+					hooks = append(hooks, "complete")
+					completedCode = exitCode
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			run := r.RunAt(start)
+			if !slices.Equal(hooks, tt.wantHooks) {
+				t.Errorf("hooks = %v, want %v", hooks, tt.wantHooks)
+			}
+			if (run.Err != nil) != tt.wantErr {
+				t.Errorf("run error = %v, want error %t", run.Err, tt.wantErr)
+			}
+			if run.ExitCode != 3 || completedCode != 3 {
+				t.Errorf("exit code = %d, OnComplete got %d, want 3", run.ExitCode, completedCode)
+			}
+			if !run.Scheduled.Equal(start) {
+				t.Errorf("Scheduled = %s, want %s", run.Scheduled, start)
+			}
+		})
+	}
+}
+
+func TestRunEnvironment(t *testing.T) {
+	var stdout bytes.Buffer
+	r, err := New(Options{
+		Schedule: "@every 1m",
+		Name:     "backup",
+		Command:  "sh",
+		Args:     []string{"-c", `echo "$CRONX_JOB $CRONX_ATTEMPT $GREETING"`},
+		Env:      []string{"GREETING=hello"},
+		Stdout:   &stdout,
+		Logger:   slog.New(slog.DiscardHandler),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run := r.RunAt(start); run.Err != nil {
+		t.Fatal(run.Err)
+	}
+	if got, want := stdout.String(), "backup 1 hello\n"; got != want {
+		t.Errorf("command printed %q, want %q", got, want)
+	}
+}
+
+func TestNewSchedulesJob(t *testing.T) {
+	// Every schedule parses on its own; the options decide whether the
+	// Runner can schedule it.
+	tests := []struct {
+		name     string
+		opts     Options
+		wantNext time.Duration
+		wantErr  bool
+	}{
+		{name: "cron expression", opts: Options{Schedule: "*/15 * * * *"}, wantNext: 15 * time.Minute},
+		{name: "@every", opts: Options{Schedule: "@every 1m"}, wantNext: time.Minute},
+		{name: "@after", opts: Options{Schedule: "@after 5m"}, wantNext: 5 * time.Minute},
+		{name: "adaptive", opts: Options{Schedule: "@every 10s", Adaptive: &AdaptiveInterval{Max: time.Hour}}, wantNext: 10 * time.Second},
+		{name: "adaptive cron expression", opts: Options{Schedule: "*/5 * * * *", Adaptive: &AdaptiveInterval{Max: time.Hour}}, wantErr: true},
+		{name: "adaptive @after", opts: Options{Schedule: "@after 1m", Adaptive: &AdaptiveInterval{Max: time.Hour}}, wantErr: true},
+		{name: "adaptive maximum below interval", opts: Options{Schedule: "@every 1h", Adaptive: &AdaptiveInterval{Max: time.Minute}}, wantErr: true},
+		{name: "adaptive aligned", opts: Options{Schedule: "@every 1m", AlignFirstRun: true, Adaptive: &AdaptiveInterval{Max: time.Hour}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.opts.Schedule, DefaultSyntax); err != nil {
+				t.Fatalf("Parse(%q): %v", tt.opts.Schedule, err)
+			}
+			clock := newFakeClock(start)
+			tt.opts.Command = "true"
+			tt.opts.Clock = clock
+			tt.opts.Location = time.UTC
+			tt.opts.Logger = slog.New(slog.DiscardHandler)
+			r, err := New(tt.opts)
+			if tt.wantErr {
+				if err == nil || r != nil {
+					t.Fatalf("New = %v, %v, want only an error", r, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.Start()
+			defer r.Stop()
+			clock.waitForTimers(t, 1)
+			if want := start.Add(tt.wantNext); !r.Next().Equal(want) {
+				t.Errorf("Next() = %s, want %s", r.Next(), want)
+			}
+		})
+	}
+}
+
+// drainRecorder records the hooks called for runs that fire while the
+// Runner is stopping.
+type drainRecorder struct {
+	started atomic.Bool
+	skipped chan SkipReason
+}
+
+func newDrainRecorder(opts *Options) *drainRecorder {
+	d := &drainRecorder{skipped: make(chan SkipReason, 1)}
+	opts.OnStart = func(*Run) { d.started.Store(true) }
+	opts.OnSkip = func(_ *Run, reason SkipReason) { d.skipped <- reason }
+	return d
+}
+
+// expectSkipped fails unless the run was skipped for shutdown without
+// being started.
+func (d *drainRecorder) expectSkipped(t *testing.T, done <-chan *Run) {
+	t.Helper()
+	select {
+	case reason := <-d.skipped:
+		if reason != SkipShutdown {
+			t.Errorf("skipped as %q, want %q", reason, SkipShutdown)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("run was not skipped")
+	}
+	if d.started.Load() {
+		t.Error("OnStart was called for a run fired during the drain")
+	}
+	expectNoRun(t, done)
+}
+
+// stopAsync calls r.Stop in its own goroutine and returns a channel that
+// is closed once it has returned.
+func stopAsync(r *Runner) <-chan struct{} {
+	stopped := make(chan struct{})
+	go func() {
+		r.Stop()
+		close(stopped)
+	}()
+	return stopped
+}
+
+func waitStopped(t *testing.T, stopped <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-stopped:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Stop did not return")
+	}
+}
+
+func TestTickDuringDrainIsSkipped(t *testing.T) {
+	clock := newFakeClock(start)
+	opts := Options{Schedule: "@every 1m"}
+	d := newDrainRecorder(&opts)
+	r, done := newTestRunner(t, clock, opts)
+	r.Start()
+	clock.waitForTimers(t, 1)
+
+	// The loop's timer has fired, but Stop is called before the run gets
+	// going: the window the check at the top of a run closes.
+	r.cancel()
+	r.wg.Add(1)
+	r.run()
+	d.expectSkipped(t, done)
+	waitStopped(t, stopAsync(r))
+}
+
+func TestTickDuringJitterIsSkipped(t *testing.T) {
+	clock := newFakeClock(start)
+	opts := Options{Schedule: "@every 1h", Jitter: 10 * time.Minute, JitterSeed: 1}
+	d := newDrainRecorder(&opts)
+	r, done := newTestRunner(t, clock, opts)
+	r.Start()
+
+	clock.waitForTimers(t, 1)
+	clock.Advance(time.Hour)
+	// The run is waiting out its jitter next to the loop's timer.
+	clock.waitForTimers(t, 2)
+	stopped := stopAsync(r)
+	d.expectSkipped(t, done)
+	waitStopped(t, stopped)
+}
+
+func TestStopDuringAdmissionSkipsRun(t *testing.T) {
+	clock := newFakeClock(start)
+	var r *Runner
+	var stopped <-chan struct{}
+	opts := Options{
+		Schedule: "@every 1m",
+		Admit: func(*Run) error {
+			// Stop arrives while the admission check is in flight.
+			stopped = stopAsync(r)
+			<-r.ctx.Done()
+			return nil
+		},
+	}
+	d := newDrainRecorder(&opts)
+	r, done := newTestRunner(t, clock, opts)
+	r.Start()
+
+	clock.waitForTimers(t, 1)
+	clock.Advance(time.Minute)
+	d.expectSkipped(t, done)
+	waitStopped(t, stopped)
+}
+
+func TestAdaptiveTimeoutFollowsInterval(t *testing.T) {
+	clock := newFakeClock(start)
+	r, done := newTestRunner(t, clock, Options{
+		Schedule:       "@every 10s",
+		Command:        "sh",
+		Args:           []string{"-c", "exit 3"},
+		Adaptive:       &AdaptiveInterval{NoopCode: 3, Max: time.Minute},
+		TimeoutPercent: 50,
+	})
+	r.Start()
+	defer r.Stop()
+
+	// Each no-op run doubles the interval the next timeout is taken of.
+	for _, interval := range []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second} {
+		clock.waitForTimers(t, 1)
+		clock.Advance(interval)
+		run := waitRun(t, done)
+		if want := interval / 2; run.Timeout != want {
+			t.Errorf("run on a %s interval has timeout %s, want %s", interval, run.Timeout, want)
+		}
+	}
+}
+
+func TestTimeoutUsesLocation(t *testing.T) {
+	// A daily run at local midnight five hours behind UTC: taken in UTC,
+	// the next midnight would be 19 hours away instead of 24.
+	loc := time.FixedZone("UTC-5", -5*60*60)
+	r, err := New(Options{
+		Schedule:       "0 0 * * *",
+		Command:        "true",
+		Location:       loc,
+		TimeoutPercent: 50,
+		Logger:         slog.New(slog.DiscardHandler),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := r.RunAt(time.Date(2025, time.January, 6, 5, 0, 0, 0, time.UTC))
+	if want := 12 * time.Hour; run.Timeout != want {
+		t.Errorf("Timeout = %s, want %s", run.Timeout, want)
+	}
+}
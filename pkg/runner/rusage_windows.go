@@ -0,0 +1,17 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+//go:build windows
+
+package runner
+
+import "os"
+
+// maxRSS is unavailable on Windows, whose process state carries no rusage.
+func maxRSS(state *os.ProcessState) (int64, bool) {
+	return 0, false
+}
@@ -0,0 +1,304 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// DefaultSyntax is the schedule syntax used when none is selected.
+const DefaultSyntax = "optional-seconds"
+
+// syntaxes maps syntax names to parser options. All of them accept
+// descriptors (@daily, @weekly) and @every.
+var syntaxes = map[string]cron.ParseOption{
+	// Five fields with an optional leading seconds field.
+	"optional-seconds": cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+	// Classic five-field cron without seconds.
+	"standard": cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+	// Six fields with a mandatory leading seconds field.
+	"with-seconds": cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+	// An alias for with-seconds, the field order Quartz uses. Parse
+	// rejects what Quartz reads differently; see checkQuartz.
+	"quartz": cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+}
+
+// NewParser returns the schedule parser for the named syntax:
+// optional-seconds, standard, with-seconds or quartz.
+func NewParser(syntax string) (cron.Parser, error) {
+	opt, ok := syntaxes[syntax]
+	if !ok {
+		return cron.Parser{}, fmt.Errorf("unknown cron syntax '%s'", syntax)
+	}
+	return cron.NewParser(opt), nil
+}
+
+// afterSchedule is the @after pseudo-schedule: each run starts Delay
+// after the previous one finished. Next only knows the start time, so the
+// Runner calls it again once a run completes.
+type afterSchedule struct {
+	Delay time.Duration
+}
+
+// Next returns t plus the delay.
+func (s afterSchedule) Next(t time.Time) time.Time {
+	return t.Add(s.Delay)
+}
+
+// adaptiveSchedule is an @every schedule whose interval doubles after
+// each run that found no work, up to max, and drops back to min after
+// one that did. Like @after, the next run is timed from when the
+// previous one finished, so the Runner only touches it from its loop.
+type adaptiveSchedule struct {
+	min, max time.Duration
+	cur      time.Duration
+}
+
+// Next returns t plus the current interval.
+func (s *adaptiveSchedule) Next(t time.Time) time.Time {
+	return t.Add(s.cur)
+}
+
+// record adjusts the interval after a run and reports whether it changed.
+func (s *adaptiveSchedule) record(noop bool) bool {
+	prev := s.cur
+	if noop {
+		s.cur = min(s.cur*2, s.max)
+	} else {
+		s.cur = s.min
+	}
+	return s.cur != prev
+}
+
+// Parse parses spec in the named syntax. Besides everything the syntax's
+// parser accepts, it understands "@after <duration>", which schedules
+// each run a fixed delay after the previous run finished.
+func Parse(spec, syntax string) (cron.Schedule, error) {
+	if rest, ok := strings.CutPrefix(spec, "@after "); ok {
+		delay, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil || delay <= 0 {
+			return nil, fmt.Errorf("invalid schedule '%s': @after needs a positive duration", spec)
+		}
+		return afterSchedule{Delay: delay}, nil
+	}
+
+	parser, err := NewParser(syntax)
+	if err != nil {
+		return nil, err
+	}
+	if syntax == "quartz" {
+		if err := checkQuartz(spec); err != nil {
+			return nil, fmt.Errorf("invalid schedule '%s' for %s syntax: %w", spec, syntax, err)
+		}
+	}
+	sched, err := parser.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule '%s' for %s syntax: %w", spec, syntax, err)
+	}
+	return sched, nil
+}
+
+// checkQuartz rejects the parts of a Quartz expression that the
+// with-seconds parser would misread or not understand: the year field,
+// the L, W and # day tokens and numeric weekdays, which Quartz counts
+// from 1 = SUN rather than 0 = SUN. "?" is accepted, as by every syntax.
+func checkQuartz(spec string) error {
+	fields := strings.Fields(spec)
+	if len(fields) > 0 && (strings.HasPrefix(fields[0], "TZ=") || strings.HasPrefix(fields[0], "CRON_TZ=")) {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 || strings.HasPrefix(fields[0], "@") {
+		return nil
+	}
+	if len(fields) == 7 {
+		return errors.New("the Quartz year field is not supported")
+	}
+	if len(fields) != 6 {
+		return nil
+	}
+	if strings.ContainsAny(strings.ToUpper(fields[3]), "LW") {
+		return errors.New("the Quartz L and W day-of-month tokens are not supported")
+	}
+	for part := range strings.SplitSeq(strings.ToUpper(fields[5]), ",") {
+		days, _, _ := strings.Cut(part, "/")
+		switch {
+		case strings.Contains(days, "#"):
+			return errors.New("the Quartz # day-of-week token is not supported")
+		case strings.Contains(days, "L"):
+			// No weekday name contains an L.
+			return errors.New("the Quartz L day-of-week token is not supported")
+		case strings.ContainsAny(days, "0123456789"):
+			return errors.New("numeric weekdays are counted differently by Quartz; use names such as MON-FRI")
+		}
+	}
+	return nil
+}
+
+// describeField renders a cron field bitmask as a list of values and
+// ranges, e.g. "0-5,30", or "*" when the field matches everything.
+func describeField(bits uint64, lo, hi uint) string {
+	const starBit = 1 << 63
+	if bits&starBit != 0 {
+		return "*"
+	}
+
+	var parts []string
+	for v := lo; v <= hi; v++ {
+		if bits&(1<<v) == 0 {
+			continue
+		}
+		end := v
+		for end+1 <= hi && bits&(1<<(end+1)) != 0 {
+			end++
+		}
+		if end == v {
+			parts = append(parts, strconv.Itoa(int(v)))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", v, end))
+		}
+		v = end
+	}
+	return strings.Join(parts, ",")
+}
+
+// ambiguousFields returns the other reading of spec when the
+// optional-seconds syntax takes six fields as seconds first, while
+// AWS-style crons read the same fields as minute to day of week followed
+// by a year. It returns nil when spec is not ambiguous or that reading
+// does not parse; the year is ignored.
+func ambiguousFields(spec, syntax string) cron.Schedule {
+	if syntax != "optional-seconds" {
+		return nil
+	}
+	fields := strings.Fields(spec)
+	var prefix []string
+	if len(fields) > 0 && (strings.HasPrefix(fields[0], "TZ=") || strings.HasPrefix(fields[0], "CRON_TZ=")) {
+		prefix, fields = fields[:1], fields[1:]
+	}
+	if len(fields) != 6 {
+		return nil
+	}
+	parser, _ := NewParser("standard")
+	alt, err := parser.Parse(strings.Join(append(prefix, fields[:5]...), " "))
+	if err != nil {
+		return nil
+	}
+	return alt
+}
+
+// logSchedule reports the normalized form of a parsed schedule at debug level.
+func logSchedule(log *slog.Logger, schedule string, sched cron.Schedule) {
+	switch s := sched.(type) {
+	case *cron.SpecSchedule:
+		log.Debug("resolved schedule",
+			"schedule", schedule,
+			"type", "spec",
+			"second", describeField(s.Second, 0, 59),
+			"minute", describeField(s.Minute, 0, 59),
+			"hour", describeField(s.Hour, 0, 23),
+			"dom", describeField(s.Dom, 1, 31),
+			"month", describeField(s.Month, 1, 12),
+			"dow", describeField(s.Dow, 0, 6),
+			"location", s.Location.String(),
+		)
+	case cron.ConstantDelaySchedule:
+		log.Debug("resolved schedule", "schedule", schedule, "type", "every", "delay", s.Delay.String())
+	case afterSchedule:
+		log.Debug("resolved schedule", "schedule", schedule, "type", "after", "delay", s.Delay.String())
+	default:
+		log.Debug("resolved schedule", "schedule", schedule, "type", fmt.Sprintf("%T", sched))
+	}
+}
+
+// interval returns the time between the run firing at t and the next one.
+// Constant-delay and @after schedules use their delay and adaptive ones
+// their current interval; others use the next-fire delta.
+func interval(sched cron.Schedule, t time.Time) time.Duration {
+	switch s := sched.(type) {
+	case cron.ConstantDelaySchedule:
+		return s.Delay
+	case afterSchedule:
+		return s.Delay
+	case *adaptiveSchedule:
+		return s.cur
+	}
+	return sched.Next(t).Sub(t)
+}
+
+// offsetSchedule shifts every fire time of a schedule by a fixed offset,
+// which may be negative.
+type offsetSchedule struct {
+	cron.Schedule
+	offset time.Duration
+}
+
+// Next returns the next fire time after t, shifted by the offset.
+func (s offsetSchedule) Next(t time.Time) time.Time {
+	next := s.Schedule.Next(t.Add(-s.offset))
+	if next.IsZero() {
+		return next
+	}
+	return next.Add(s.offset)
+}
+
+// WithOffset wraps sched so that every fire time is shifted by offset.
+func WithOffset(sched cron.Schedule, offset time.Duration) cron.Schedule {
+	if offset == 0 {
+		return sched
+	}
+	return offsetSchedule{Schedule: sched, offset: offset}
+}
+
+// alignedSchedule is a constant-delay schedule whose first fire time
+// snaps to the next multiple of the delay since midnight.
+type alignedSchedule struct {
+	cron.ConstantDelaySchedule
+	log     *slog.Logger
+	aligned bool
+}
+
+// Next returns the aligned boundary after t on the first call and then
+// fires every delay.
+func (s *alignedSchedule) Next(t time.Time) time.Time {
+	if s.aligned {
+		return s.ConstantDelaySchedule.Next(t)
+	}
+	s.aligned = true
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	next := midnight.Add((t.Sub(midnight)/s.Delay + 1) * s.Delay)
+	s.log.Info("first run aligned", "next_run", next, "delay", s.Delay.String())
+	return next
+}
+
+// AlignFirst makes the first fire time of an @every schedule snap to the
+// next natural boundary, e.g. :00, :10, :20 for @every 10m, so fire times
+// do not depend on when the scheduler started. Other schedules are
+// returned unchanged. The result must not be shared between schedulers.
+func AlignFirst(sched cron.Schedule, log *slog.Logger) cron.Schedule {
+	s, ok := sched.(cron.ConstantDelaySchedule)
+	if !ok {
+		return sched
+	}
+	return &alignedSchedule{ConstantDelaySchedule: s, log: log}
+}
+
+// offsetTooLarge reports whether offset is not smaller than the
+// interval of sched.
+func offsetTooLarge(sched cron.Schedule, offset time.Duration, now time.Time) bool {
+	if offset < 0 {
+		offset = -offset
+	}
+	return offset != 0 && offset >= interval(sched, sched.Next(now))
+}
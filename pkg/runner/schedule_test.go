@@ -0,0 +1,41 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package runner
+
+import "testing"
+
+func TestParseQuartz(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{spec: "0 */5 * * * *"},
+		{spec: "0 0 12 ? * MON-FRI"},
+		{spec: "0 0 12 * JUL ?"},
+		{spec: "0 0 12 1/2 * ?"},
+		{spec: "0 0 12 ? * MON/2"},
+		{spec: "TZ=UTC 0 0 12 * * SUN"},
+		{spec: "@daily"},
+		{spec: "0 0 12 * * ? 2026", wantErr: true},
+		{spec: "0 0 12 L * ?", wantErr: true},
+		{spec: "0 0 12 15W * ?", wantErr: true},
+		{spec: "0 0 12 LW * ?", wantErr: true},
+		{spec: "0 0 12 ? * 6#3", wantErr: true},
+		{spec: "0 0 12 ? * 6L", wantErr: true},
+		{spec: "0 0 12 ? * 2-6", wantErr: true},
+		{spec: "*/5 * * * *", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := Parse(tt.spec, "quartz")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse(%q, quartz) error = %v, want error %t", tt.spec, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"context"
+	"os/exec"
+	"time"
+)
+
+// probe periodically runs a liveness check command.
+type probe struct {
+	argv     []string
+	interval time.Duration
+	// failures is how many consecutive failures raise an alert.
+	failures int
+}
+
+// run executes the probe every interval for as long as the process runs.
+// Each attempt is bounded by the interval.
+func (p *probe) run() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	failed := 0
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), p.interval)
+		err := exec.CommandContext(ctx, p.argv[0], p.argv[1:]...).Run()
+		cancel()
+
+		if err == nil {
+			if failed >= p.failures {
+				logger.Info("liveness probe recovered", "failures", failed)
+			}
+			failed = 0
+			continue
+		}
+		failed++
+		logger.Warn("liveness probe failed", "command", p.argv[0], "attempt", failed, "error", err)
+		if failed == p.failures {
+			logger.Error("liveness probe failing", "command", p.argv[0], "failures", failed)
+		}
+	}
+}
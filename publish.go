@@ -0,0 +1,159 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/focela/cronx/pkg/runner"
+)
+
+// Run events, as passed to --publish-command.
+const (
+	publishStart    = "start"
+	publishError    = "error"
+	publishComplete = "complete"
+)
+
+// runEvent is the JSON document piped to --publish-command.
+type runEvent struct {
+	Event       string    `json:"event"`
+	Job         string    `json:"job"`
+	Description string    `json:"description,omitempty"`
+	RunID       string    `json:"run_id"`
+	Attempt     int       `json:"attempt"`
+	Scheduled   time.Time `json:"scheduled"`
+	Started     time.Time `json:"started,omitzero"`
+	Duration    string    `json:"duration,omitempty"`
+	ExitCode    *int      `json:"exit_code,omitempty"`
+	Success     *bool     `json:"success,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// publishQueueSize bounds the events waiting for --publish-command.
+const publishQueueSize = 64
+
+// publisher pipes run events to an external command, one invocation per
+// event, so any message broker with a CLI client can receive them. Events
+// are published in order by a single goroutine so that a slow command
+// never delays a run.
+type publisher struct {
+	command string
+	args    []string
+	timeout time.Duration
+
+	queue chan queuedEvent
+	done  chan struct{}
+}
+
+// queuedEvent is an encoded run event waiting to be published.
+type queuedEvent struct {
+	event string
+	runID string
+	body  []byte
+}
+
+// newPublisher splits the --publish-command value into a command line and
+// starts publishing.
+func newPublisher(spec string, timeout time.Duration) (*publisher, error) {
+	words, err := splitWords(spec)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) == 0 {
+		return nil, errors.New("must not be empty")
+	}
+	p := &publisher{
+		command: words[0],
+		args:    words[1:],
+		timeout: timeout,
+		queue:   make(chan queuedEvent, publishQueueSize),
+		done:    make(chan struct{}),
+	}
+	go p.run()
+	return p, nil
+}
+
+// publish queues event for run. The event is encoded right away, since
+// run keeps changing after the hook returns. Failures are logged and
+// otherwise ignored so that a broker outage never affects the job.
+func (p *publisher) publish(event string, run *runner.Run) {
+	ev := runEvent{
+		Event:       event,
+		Job:         run.Job,
+		Description: run.Description,
+		RunID:       run.ID,
+		Attempt:     run.Attempt,
+		Scheduled:   run.Scheduled,
+		Started:     run.Started,
+	}
+	if event != publishStart {
+		success := run.Err == nil
+		ev.Duration = run.Duration.String()
+		ev.ExitCode = &run.ExitCode
+		ev.Success = &success
+		if run.Err != nil {
+			ev.Error = run.Err.Error()
+		}
+	}
+	body, err := json.Marshal(ev)
+	if err != nil {
+		logger.Error("failed to encode run event", "event", event, "run_id", run.ID, "error", err)
+		return
+	}
+	select {
+	case p.queue <- queuedEvent{event: event, runID: run.ID, body: body}:
+	default:
+		logger.Warn("dropping run event, publish queue is full", "event", event, "run_id", run.ID, "queued", publishQueueSize)
+	}
+}
+
+// run publishes queued events until close is called.
+func (p *publisher) run() {
+	defer close(p.done)
+	for ev := range p.queue {
+		p.send(ev)
+	}
+}
+
+// send runs the publish command for one event.
+func (p *publisher) send(ev queuedEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, p.command, p.args...)
+	cmd.Stdin = bytes.NewReader(append(ev.body, '\n'))
+	cmd.Env = append(os.Environ(), "CRONX_EVENT="+ev.event)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		logger.Warn("failed to publish run event",
+			"event", ev.event,
+			"run_id", ev.runID,
+			"error", err,
+			"output", strings.TrimSpace(string(out)),
+		)
+		return
+	}
+	logger.Debug("published run event", "event", ev.event, "run_id", ev.runID)
+}
+
+// close stops accepting events and waits up to the publish timeout for
+// the queued ones to go out.
+func (p *publisher) close() {
+	close(p.queue)
+	select {
+	case <-p.done:
+	case <-time.After(p.timeout):
+		logger.Warn("gave up publishing queued run events", "pending", len(p.queue))
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/focela/cronx/pkg/runner"
+)
+
+// reporter periodically logs a summary of runs since the last report.
+type reporter struct {
+	runner   *runner.Runner
+	interval time.Duration
+	done     chan struct{}
+
+	mu       sync.Mutex
+	runs     int
+	failures int
+}
+
+// newReporter returns a reporter logging every interval once started.
+func newReporter(interval time.Duration) *reporter {
+	return &reporter{interval: interval, done: make(chan struct{})}
+}
+
+// record counts a finished run towards the next report.
+func (r *reporter) record(success bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.runs++
+	if !success {
+		r.failures++
+	}
+}
+
+// run logs a report every interval until stop is called.
+func (r *reporter) run() {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-r.done:
+			return
+		}
+		r.mu.Lock()
+		runs, failures := r.runs, r.failures
+		r.runs, r.failures = 0, 0
+		r.mu.Unlock()
+
+		logger.Info("status report",
+			"interval", r.interval.String(),
+			"runs", runs,
+			"failures", failures,
+			"running", len(r.runner.Running()),
+			"next_run", r.runner.Next(),
+		)
+	}
+}
+
+// stop ends the reporting loop.
+func (r *reporter) stop() {
+	close(r.done)
+}
@@ -0,0 +1,66 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sdNotify sends state to the systemd notification socket. It is a no-op
+// when cronx is not started by systemd with Type=notify.
+func sdNotify(state string) {
+	path := os.Getenv("NOTIFY_SOCKET")
+	if path == "" {
+		return
+	}
+	// A leading @ names a socket in the abstract namespace.
+	if strings.HasPrefix(path, "@") {
+		path = "\x00" + path[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		logger.Error("failed to connect to systemd notify socket", "error", err)
+		return
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		logger.Error("failed to notify systemd", "state", state, "error", err)
+	}
+}
+
+// sdWatchdogInterval returns how often systemd expects a WATCHDOG=1 ping,
+// or zero if the watchdog is not enabled for this process.
+func sdWatchdogInterval() time.Duration {
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
+
+// sdWatchdog pings the systemd watchdog at half its timeout for as long
+// as the process runs, including while jobs drain on shutdown.
+func sdWatchdog() {
+	every := sdWatchdogInterval()
+	if every == 0 {
+		return
+	}
+	logger.Info("systemd watchdog enabled", "interval", (every / 2).String())
+	ticker := time.NewTicker(every / 2)
+	defer ticker.Stop()
+	for range ticker.C {
+		sdNotify("WATCHDOG=1")
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/focela/cronx/pkg/runner"
+)
+
+// selftestSignalTimeout bounds how long the signal check waits for
+// delivery.
+const selftestSignalTimeout = time.Second
+
+// selfCheck is one step of cronx selftest. A check returning
+// errors.ErrUnsupported is reported as skipped.
+type selfCheck struct {
+	name string
+	run  func() error
+}
+
+// selftest runs quick checks of the binary in the current environment
+// and reports whether all of them passed.
+func selftest() bool {
+	checks := []selfCheck{
+		{"parse schedule", checkParse},
+		{"execute command", checkExecute},
+		{"logging", checkLogging},
+		{"signal handling", checkSignals},
+	}
+	passed := true
+	for _, c := range checks {
+		err := c.run()
+		switch {
+		case errors.Is(err, errors.ErrUnsupported):
+			fmt.Printf("SKIP %s: not supported on this platform\n", c.name)
+		case err != nil:
+			fmt.Printf("FAIL %s: %v\n", c.name, err)
+			passed = false
+		default:
+			fmt.Printf("PASS %s\n", c.name)
+		}
+	}
+	if passed {
+		fmt.Println("selftest passed")
+	} else {
+		fmt.Println("selftest failed")
+	}
+	return passed
+}
+
+// checkParse parses a known-good schedule in every supported syntax,
+// including the quartz alias of with-seconds.
+func checkParse() error {
+	for _, syntax := range []string{"optional-seconds", "standard", "with-seconds", "quartz"} {
+		spec := "*/5 * * * *"
+		if syntax == "with-seconds" || syntax == "quartz" {
+			spec = "0 */5 * * * MON-FRI"
+		}
+		sched, err := runner.Parse(spec, syntax)
+		if err != nil {
+			return fmt.Errorf("%s: %w", syntax, err)
+		}
+		if sched.Next(time.Now()).IsZero() {
+			return fmt.Errorf("%s: '%s' never fires", syntax, spec)
+		}
+	}
+	return nil
+}
+
+// checkExecute runs "cronx version" through the runner, which exercises
+// the same create and execute paths as a scheduled run without
+// depending on any other program being installed.
+func checkExecute() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	r, err := runner.New(runner.Options{
+		Schedule: "@daily",
+		Command:  exe,
+		Args:     []string{"version"},
+		Stdout:   io.Discard,
+		Stderr:   io.Discard,
+		Logger:   slog.New(slog.DiscardHandler),
+	})
+	if err != nil {
+		return err
+	}
+	if run := r.RunAt(time.Now()); run.Err != nil {
+		return run.Err
+	}
+	return nil
+}
+
+// checkLogging writes a record through a JSON handler and reads it back.
+func checkLogging() error {
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("selftest", "check", "logging")
+	var record struct {
+		Msg   string `json:"msg"`
+		Check string `json:"check"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		return fmt.Errorf("unreadable log record: %w", err)
+	}
+	if record.Msg != "selftest" || record.Check != "logging" {
+		return fmt.Errorf("unexpected log record %s", bytes.TrimSpace(buf.Bytes()))
+	}
+	return nil
+}
+
+// checkSignals installs the shutdown handler and delivers SIGTERM to
+// cronx itself, which the handler must catch.
+func checkSignals() error {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	if err := raise(syscall.SIGTERM); err != nil {
+		return err
+	}
+	select {
+	case <-sigChan:
+		return nil
+	case <-time.After(selftestSignalTimeout):
+		return fmt.Errorf("SIGTERM not delivered within %s", selftestSignalTimeout)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/focela/cronx/pkg/runner"
+)
+
+// writeJSON encodes v as the response body.
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Error("failed to write http response", "error", err)
+	}
+}
+
+// scheduleStatus is the scheduler's current plan as reported over HTTP.
+type scheduleStatus struct {
+	Job      string                    `json:"job"`
+	Schedule string                    `json:"schedule"`
+	NextRun  time.Time                 `json:"next_run"`
+	Running  int                       `json:"running"`
+	Runs     int                       `json:"runs"`
+	LastRun  *runRecord                `json:"last_run"`
+	Skipped  map[runner.SkipReason]int `json:"skipped"`
+}
+
+// startHTTPServer serves the status endpoints on addr. The listener is
+// bound before returning so that address errors surface at startup.
+func startHTTPServer(addr string, h *history, skips *skipCounter, rn *runner.Runner, schedule string) (*http.Server, error) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /runs", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, h.list())
+	})
+	mux.HandleFunc("GET /schedule", func(w http.ResponseWriter, r *http.Request) {
+		status := scheduleStatus{
+			Job:      rn.Name(),
+			Schedule: schedule,
+			NextRun:  rn.Next(),
+			Running:  len(rn.Running()),
+			Skipped:  skips.snapshot(),
+		}
+		if last, total, ok := h.last(); ok {
+			status.Runs, status.LastRun = total, &last
+		}
+		writeJSON(w, status)
+	})
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	srv := &http.Server{Handler: mux}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("http server failed", "error", err)
+		}
+	}()
+	logger.Info("http server listening", "addr", ln.Addr().String())
+	return srv, nil
+}
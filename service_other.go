@@ -0,0 +1,26 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// serviceStop is always nil outside Windows.
+var serviceStop chan os.Signal
+
+// stopService does nothing outside Windows.
+func stopService(code int) {}
+
+// service fails; services are only supported on Windows.
+func service(args []string) (bool, error) {
+	return false, errors.New("only supported on Windows")
+}
@@ -0,0 +1,165 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// serviceName is the name cronx is registered under with the service
+// control manager.
+const serviceName = "cronx"
+
+// serviceStopTimeout bounds how long exit waits for the service control
+// manager to acknowledge that the service stopped.
+const serviceStopTimeout = 10 * time.Second
+
+var (
+	// serviceStop receives a SIGTERM when the service control manager
+	// asks cronx to stop. It is nil unless running as a service.
+	serviceStop chan os.Signal
+	// serviceExit passes the exit code to the handler, which then
+	// returns so that svc.Run reports the service as stopped.
+	serviceExit chan uint32
+	// serviceDone is closed once svc.Run has returned.
+	serviceDone chan struct{}
+)
+
+// service handles "cronx service install|run|uninstall". It reports
+// whether main should go on to run the scheduler, which is only the case
+// for run; os.Args is then rewritten to the flags and positionals after it.
+func service(args []string) (bool, error) {
+	if len(args) == 0 {
+		return false, errors.New("expected install, run or uninstall")
+	}
+	switch args[0] {
+	case "install":
+		return false, installService(args[1:])
+	case "uninstall":
+		return false, uninstallService()
+	case "run":
+		os.Args = append([]string{os.Args[0]}, args[1:]...)
+		serviceStop = make(chan os.Signal, 1)
+		serviceExit = make(chan uint32, 1)
+		serviceDone = make(chan struct{})
+		go func() {
+			err := svc.Run(serviceName, serviceHandler{})
+			close(serviceDone)
+			if err != nil {
+				logger.Error("failed to run as a service", "error", err)
+				exit(1)
+			}
+		}()
+		return true, nil
+	}
+	return false, fmt.Errorf("unknown service command '%s': expected install, run or uninstall", args[0])
+}
+
+// installService registers the running executable as an automatically
+// started service that runs with args.
+func installService(args []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate executable: %w", err)
+	}
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to service manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	if s, err := m.OpenService(serviceName); err == nil {
+		s.Close()
+		return fmt.Errorf("service %s already exists", serviceName)
+	}
+	s, err := m.CreateService(serviceName, exe, mgr.Config{
+		DisplayName: "cronx",
+		Description: "Runs a command on a cron schedule",
+		StartType:   mgr.StartAutomatic,
+	}, append([]string{"service", "run"}, args...)...)
+	if err != nil {
+		return fmt.Errorf("failed to create service: %w", err)
+	}
+	s.Close()
+	logger.Info("installed service", "name", serviceName, "args", args)
+	return nil
+}
+
+// uninstallService removes the service registration.
+func uninstallService() error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to service manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(serviceName)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed: %w", serviceName, err)
+	}
+	defer s.Close()
+	if err := s.Delete(); err != nil {
+		return fmt.Errorf("failed to delete service: %w", err)
+	}
+	logger.Info("uninstalled service", "name", serviceName)
+	return nil
+}
+
+// stopService hands code to the service handler and waits for svc.Run to
+// report the service as stopped, so that the service control manager
+// never sees the process vanish while the stop is still pending. It
+// returns right away unless running as a service.
+func stopService(code int) {
+	if serviceExit == nil {
+		return
+	}
+	select {
+	case serviceExit <- uint32(code):
+	default:
+	}
+	select {
+	case <-serviceDone:
+	case <-time.After(serviceStopTimeout):
+	}
+}
+
+// serviceHandler translates service control requests into the graceful
+// shutdown path, and returns once exit reports that cronx is done.
+type serviceHandler struct{}
+
+// Execute implements svc.Handler.
+func (serviceHandler) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case code := <-serviceExit:
+			// A non-zero code is reported as service-specific.
+			return code != 0, code
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				select {
+				case serviceStop <- syscall.SIGTERM:
+				default:
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// signalNames maps the signal names accepted on the command line.
+var signalNames = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+	"TERM": syscall.SIGTERM,
+}
+
+// dumpSignal requests a state dump without shutting down.
+var dumpSignal os.Signal = syscall.SIGUSR2
+
+// raise sends sig to the cronx process itself.
+func raise(sig syscall.Signal) error {
+	return syscall.Kill(os.Getpid(), sig)
+}
@@ -0,0 +1,130 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSIGTERMDrainsRunningJob(t *testing.T) {
+	done := filepath.Join(t.TempDir(), "done")
+	p := startCronx(t, "@every 1s", "sh", "-c", "sleep 1; touch "+done)
+	p.waitForLog(t, "executing command")
+	if err := p.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	p.waitForLog(t, "draining running jobs")
+	finished := p.waitForLog(t, "command finished")
+	if code, _ := finished["exit_code"].(float64); code != 0 {
+		t.Errorf("job exited %v, want 0", finished["exit_code"])
+	}
+	p.waitForLog(t, "scheduler stopped successfully")
+	if code := p.wait(t); code != 0 {
+		t.Errorf("cronx exited %d, want 0", code)
+	}
+	if _, err := os.Stat(done); err != nil {
+		t.Errorf("job did not finish: %v", err)
+	}
+}
+
+func TestSIGINTAbortsRunningJob(t *testing.T) {
+	p := startCronx(t, "--abort-on-sigint", "@every 1s", "sleep", "30")
+	p.waitForLog(t, "executing command")
+	started := time.Now()
+	if err := p.cmd.Process.Signal(syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+	p.waitForLog(t, "aborting running jobs")
+	if code := p.wait(t); code != 1 {
+		t.Errorf("cronx exited %d, want 1", code)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("cronx took %s to abort, want the job killed", elapsed)
+	}
+}
+
+func TestSecondSignalKillsRunningJob(t *testing.T) {
+	p := startCronx(t, "@every 1s", "sleep", "30")
+	p.waitForLog(t, "executing command")
+	if err := p.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	p.waitForLog(t, "waiting for running jobs to complete")
+	// A quick double Ctrl-C, but a separate delivery.
+	time.Sleep(50 * time.Millisecond)
+	if err := p.cmd.Process.Signal(syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+	p.waitForLog(t, "received second signal, killing running jobs")
+	if code := p.wait(t); code != 1 {
+		t.Errorf("cronx exited %d, want 1", code)
+	}
+}
+
+func TestRepeatedSignalIsIgnored(t *testing.T) {
+	p := startCronx(t, "@every 1s", "sleep", "1")
+	p.waitForLog(t, "executing command")
+	// A supervisor signalling both the process and its group back to
+	// back. The kernel may merge the two; either way the job must be left
+	// to finish.
+	for range 2 {
+		if err := p.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if code := p.wait(t); code != 0 {
+		t.Errorf("cronx exited %d, want 0", code)
+	}
+	if p.logged("received second signal, killing running jobs") {
+		t.Error("the repeated signal killed the running job")
+	}
+}
+
+func TestBackfillSIGINTKillsRunningOccurrences(t *testing.T) {
+	pids := filepath.Join(t.TempDir(), "pids")
+	p := startCronx(t, "backfill", "--schedule", "@daily", "--from", "2025-01-01", "--to", "2025-01-10", "--parallel", "2",
+		"--", "sh", "-c", "echo $$ >> "+pids+"; exec sleep 30")
+	// Both occurrences have started once both have recorded their pid.
+	var running []string
+	for deadline := time.Now().Add(10 * time.Second); len(running) < 2; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("occurrences %q started, want 2", running)
+		}
+		data, _ := os.ReadFile(pids)
+		running = strings.Fields(string(data))
+	}
+	if err := p.cmd.Process.Signal(syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+	finished := p.waitForLog(t, "backfill finished")
+	if runs, _ := finished["runs"].(float64); runs != 2 {
+		t.Errorf("backfill ran %v occurrences, want the 2 running ones", finished["runs"])
+	}
+	if code := p.wait(t); code != 1 {
+		t.Errorf("cronx exited %d, want 1", code)
+	}
+
+	for _, pid := range running {
+		n, err := strconv.Atoi(pid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if syscall.Kill(n, 0) == nil {
+			syscall.Kill(n, syscall.SIGKILL)
+			t.Errorf("occurrence %d survived the backfill", n)
+		}
+	}
+}
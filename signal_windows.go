@@ -0,0 +1,31 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// signalNames maps the signal names accepted on the command line.
+var signalNames = map[string]syscall.Signal{
+	"INT":  syscall.SIGINT,
+	"KILL": syscall.SIGKILL,
+	"TERM": syscall.SIGTERM,
+}
+
+// dumpSignal is nil because Windows has no SIGUSR2.
+var dumpSignal os.Signal
+
+// raise is unsupported because Windows cannot signal its own process.
+func raise(syscall.Signal) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,43 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"maps"
+	"sync"
+
+	"github.com/focela/cronx/pkg/runner"
+)
+
+// skipCounter counts skipped runs by reason.
+type skipCounter struct {
+	mu     sync.Mutex
+	counts map[runner.SkipReason]int
+}
+
+// add counts a skip and returns the total for its reason.
+func (c *skipCounter) add(reason runner.SkipReason) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.counts == nil {
+		c.counts = make(map[runner.SkipReason]int)
+	}
+	c.counts[reason]++
+	return c.counts[reason]
+}
+
+// snapshot returns a copy of the counts, never nil.
+func (c *skipCounter) snapshot() map[runner.SkipReason]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts := make(map[runner.SkipReason]int, len(c.counts))
+	maps.Copy(counts, c.counts)
+	return counts
+}
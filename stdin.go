@@ -0,0 +1,66 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/focela/cronx/pkg/runner"
+)
+
+// stdinFetcher downloads each run's standard input over HTTP.
+type stdinFetcher struct {
+	url     string
+	timeout time.Duration
+	// skip skips the run instead of failing it when the fetch fails.
+	skip   bool
+	client *http.Client
+}
+
+// fetch GETs the URL and returns the body for the command to read. The
+// body is read in full first, so the timeout never cuts off a run.
+func (f *stdinFetcher) fetch(run *runner.Run) (io.Reader, error) {
+	body, err := f.get()
+	if err != nil {
+		if f.skip {
+			return nil, fmt.Errorf("%w: %w", runner.ErrSkip, err)
+		}
+		return nil, err
+	}
+	logger.Info("fetched command input", "run_id", run.ID, "bytes", len(body))
+	return bytes.NewReader(body), nil
+}
+
+// get performs the request bounded by the timeout.
+func (f *stdinFetcher) get() ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdin request: %w", err)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch stdin: %w", redactURLError(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("stdin url returned status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stdin: %w", err)
+	}
+	return body, nil
+}
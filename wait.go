@@ -0,0 +1,43 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	// probeTimeout bounds each TCP dial to a --wait-for dependency.
+	probeTimeout = 2 * time.Second
+	// probeInterval is the pause between failed probes of a dependency.
+	probeInterval = time.Second
+)
+
+// waitFor dials each host:port in addrs until it accepts a connection,
+// failing if they are not all reachable within timeout.
+func waitFor(addrs []string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for _, addr := range addrs {
+		for attempt := 1; ; attempt++ {
+			conn, err := net.DialTimeout("tcp", addr, probeTimeout)
+			if err == nil {
+				conn.Close()
+				logger.Info("dependency is reachable", "addr", addr, "attempt", attempt)
+				break
+			}
+			logger.Warn("dependency is not reachable", "addr", addr, "attempt", attempt, "error", err)
+			if time.Now().Add(probeInterval).After(deadline) {
+				return fmt.Errorf("%s not reachable after %s", addr, timeout)
+			}
+			time.Sleep(probeInterval)
+		}
+	}
+	return nil
+}
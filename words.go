@@ -0,0 +1,100 @@
+// Copyright 2025 Focela Authors.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0.
+// See the LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// splitWords splits s into words like a POSIX shell would, honoring
+// single quotes, double quotes and backslash escapes. No expansion of
+// variables, globs or other shell syntax is performed.
+func splitWords(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if escaped {
+		return nil, errors.New("trailing backslash")
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// applyWrapper expands a --wrapper template into the final argv.
+// The {cmd} placeholder is replaced by command and a standalone {args}
+// word by the arguments; without {args}, the arguments follow {cmd}.
+func applyWrapper(tmpl, command string, args []string) (string, []string, error) {
+	words, err := splitWords(tmpl)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid wrapper '%s': %w", tmpl, err)
+	}
+	if !strings.Contains(tmpl, "{cmd}") {
+		return "", nil, fmt.Errorf("invalid wrapper '%s': missing {cmd} placeholder", tmpl)
+	}
+	hasArgs := strings.Contains(tmpl, "{args}")
+
+	var argv []string
+	for _, w := range words {
+		switch {
+		case w == "{args}":
+			argv = append(argv, args...)
+		case strings.Contains(w, "{args}"):
+			return "", nil, fmt.Errorf("invalid wrapper '%s': {args} must be a separate word", tmpl)
+		default:
+			argv = append(argv, strings.ReplaceAll(w, "{cmd}", command))
+			if !hasArgs && strings.Contains(w, "{cmd}") {
+				argv = append(argv, args...)
+			}
+		}
+	}
+
+	if _, err := exec.LookPath(argv[0]); err != nil {
+		return "", nil, fmt.Errorf("invalid wrapper '%s': %w", tmpl, err)
+	}
+	return argv[0], argv[1:], nil
+}